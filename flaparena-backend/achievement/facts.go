@@ -0,0 +1,85 @@
+package achievement
+
+import (
+    "time"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+)
+
+// Facts is everything rules are evaluated against for one player and game.
+type Facts struct {
+    models.GameResult
+    Wins     int           // Games won so far, this one included
+    Games    int           // Games finished so far, this one included
+    Survival time.Duration // Longest alive in a single round
+    Glide    time.Duration // Longest alive without flapping
+}
+
+// NewFacts gathers the facts about the player of result from that result,
+// their career wins and games, and the actions recorded in the game.
+func NewFacts(result models.GameResult, wins, games int, actions []models.GameAction) Facts {
+    facts := Facts{GameResult: result, Wins: wins, Games: games}
+    facts.Survival, facts.Glide = flightTimes(actions, result.UserID)
+    return facts
+}
+
+func (f Facts) stat(name string) (float64, bool) {
+    switch name {
+    case StatScore:
+        return float64(f.Score), true
+    case StatFlaps:
+        return float64(f.Flaps), true
+    case StatSurvival:
+        return f.Survival.Seconds(), true
+    case StatGlide:
+        return f.Glide.Seconds(), true
+    case StatWins:
+        return float64(f.Wins), true
+    case StatGames:
+        return float64(f.Games), true
+    default:
+        return 0, false
+    }
+}
+
+// flightTimes replays a game's actions for one player and returns the
+// longest they stayed alive in a round and the longest they went without
+// flapping while alive. Rounds run from their roundStart to the player's
+// death or the end of the round, whichever came first.
+func flightTimes(actions []models.GameAction, userID string) (survival, glide time.Duration) {
+    alive := false
+    var roundStart, lastFlap int64
+
+    land := func(timestamp int64) {
+        survival = longest(survival, timestamp-roundStart)
+        glide = longest(glide, timestamp-lastFlap)
+        alive = false
+    }
+
+    for _, action := range actions {
+        switch {
+        case action.UserID == "server" && action.Action == "roundStart":
+            alive = true
+            roundStart = action.Timestamp
+            lastFlap = action.Timestamp
+        case !alive:
+            continue
+        case action.UserID == "server" && (action.Action == "roundEnd" || action.Action == "end" || action.Action == "timeout"):
+            land(action.Timestamp)
+        case action.UserID == userID && action.Action == "dead":
+            land(action.Timestamp)
+        case action.UserID == userID && action.Action == "flap":
+            glide = longest(glide, action.Timestamp-lastFlap)
+            lastFlap = action.Timestamp
+        }
+    }
+    return survival, glide
+}
+
+// longest returns the longer of current and a span in milliseconds.
+func longest(current time.Duration, millis int64) time.Duration {
+    if span := time.Duration(millis) * time.Millisecond; span > current {
+        return span
+    }
+    return current
+}
@@ -0,0 +1,69 @@
+// Package achievement decides which achievements a player unlocked in a
+// finished game. Achievements are plain data in Rules: each one names a
+// stat and the value it has to reach, so adding one needs no code.
+package achievement
+
+// Stats rules can be written against.
+const (
+    StatScore    = "score"    // Best round score of the game
+    StatFlaps    = "flaps"    // Flaps over the whole game
+    StatSurvival = "survival" // Longest the player stayed alive in a single round, in seconds
+    StatGlide    = "glide"    // Longest the player stayed alive without flapping, in seconds
+    StatWins     = "wins"     // Games won so far, this one included
+    StatGames    = "games"    // Games finished so far, this one included
+)
+
+// Rule is a single achievement. It is unlocked by a game where Stat reaches
+// AtLeast. Rules with Win set only count games the player won.
+type Rule struct {
+    ID          string  `json:"id"`
+    Name        string  `json:"name"`
+    Description string  `json:"description"`
+    Stat        string  `json:"-"`
+    AtLeast     float64 `json:"-"`
+    Win         bool    `json:"-"`
+}
+
+// Rules lists every achievement there is. IDs are stored with unlocked
+// achievements and must never change.
+var Rules = []Rule{
+    {ID: "firstGame", Name: "Fledgling", Description: "Finish your first game.", Stat: StatGames, AtLeast: 1},
+    {ID: "firstWin", Name: "Top Bird", Description: "Win a game.", Stat: StatWins, AtLeast: 1},
+    {ID: "tenWins", Name: "Flock Leader", Description: "Win 10 games.", Stat: StatWins, AtLeast: 10},
+    {ID: "hundredWins", Name: "Apex Flyer", Description: "Win 100 games.", Stat: StatWins, AtLeast: 100},
+    {ID: "score10", Name: "Getting the Hang of It", Description: "Score 10 in one game.", Stat: StatScore, AtLeast: 10},
+    {ID: "score50", Name: "Half Century", Description: "Score 50 in one game.", Stat: StatScore, AtLeast: 50},
+    {ID: "score100", Name: "Centurion", Description: "Score 100 in one game.", Stat: StatScore, AtLeast: 100},
+    {ID: "survive2m", Name: "Endurance", Description: "Survive 2 minutes in a single round.", Stat: StatSurvival, AtLeast: 120},
+    {ID: "glide3s", Name: "Glider", Description: "Win a game after going 3 seconds without flapping.", Stat: StatGlide, AtLeast: 3, Win: true},
+    {ID: "busyWings", Name: "Busy Wings", Description: "Flap 500 times in one game.", Stat: StatFlaps, AtLeast: 500},
+}
+
+// Find returns the rule with the given ID.
+func Find(id string) (Rule, bool) {
+    for _, rule := range Rules {
+        if rule.ID == id {
+            return rule, true
+        }
+    }
+    return Rule{}, false
+}
+
+// Evaluate returns every rule the facts of a game meet.
+func Evaluate(facts Facts) []Rule {
+    var unlocked []Rule
+    for _, rule := range Rules {
+        if rule.met(facts) {
+            unlocked = append(unlocked, rule)
+        }
+    }
+    return unlocked
+}
+
+func (r Rule) met(facts Facts) bool {
+    if r.Win && !facts.Won {
+        return false
+    }
+    value, known := facts.stat(r.Stat)
+    return known && value >= r.AtLeast
+}
@@ -0,0 +1,29 @@
+// Package clock abstracts time so that code driven by timers can be run
+// against a fake clock.
+package clock
+
+import "time"
+
+// Timer is a pending call scheduled with AfterFunc.
+type Timer interface {
+    // Stop prevents the call from happening. It reports false if the call
+    // already happened or the timer was already stopped.
+    Stop() bool
+}
+
+// Clock tells the time and runs functions after a delay.
+type Clock interface {
+    Now() time.Time
+    AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Real is the system clock.
+type Real struct{}
+
+func (Real) Now() time.Time {
+    return time.Now()
+}
+
+func (Real) AfterFunc(d time.Duration, f func()) Timer {
+    return time.AfterFunc(d, f)
+}
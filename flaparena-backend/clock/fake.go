@@ -0,0 +1,90 @@
+package clock
+
+import (
+    "sync"
+    "time"
+)
+
+// Fake is a clock that only moves when told to. Timers run synchronously
+// inside Advance, in the order they fall due.
+type Fake struct {
+    mutex  sync.Mutex
+    now    time.Time
+    timers []*fakeTimer
+}
+
+func NewFake(now time.Time) *Fake {
+    return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+    f.mutex.Lock()
+    defer f.mutex.Unlock()
+
+    return f.now
+}
+
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+    f.mutex.Lock()
+    defer f.mutex.Unlock()
+
+    timer := &fakeTimer{clock: f, at: f.now.Add(d), fn: fn}
+    f.timers = append(f.timers, timer)
+    return timer
+}
+
+// Advance moves the clock forward by d, running every timer that falls due
+// on the way, including ones scheduled by the timers themselves.
+func (f *Fake) Advance(d time.Duration) {
+    f.mutex.Lock()
+    target := f.now.Add(d)
+    for {
+        next := f.nextDue(target)
+        if next == nil {
+            break
+        }
+        f.now = next.at
+        f.remove(next)
+        f.mutex.Unlock()
+        next.fn()
+        f.mutex.Lock()
+    }
+    f.now = target
+    f.mutex.Unlock()
+}
+
+// nextDue returns the earliest timer due by target. Callers must hold mutex.
+func (f *Fake) nextDue(target time.Time) *fakeTimer {
+    var next *fakeTimer
+    for _, timer := range f.timers {
+        if !timer.at.After(target) && (next == nil || timer.at.Before(next.at)) {
+            next = timer
+        }
+    }
+    return next
+}
+
+// remove drops a timer from the pending list and reports whether it was
+// still there. Callers must hold mutex.
+func (f *Fake) remove(timer *fakeTimer) bool {
+    for i, pending := range f.timers {
+        if pending == timer {
+            f.timers = append(f.timers[:i:i], f.timers[i+1:]...)
+            return true
+        }
+    }
+    return false
+}
+
+type fakeTimer struct {
+    clock *Fake
+    at    time.Time
+    fn    func()
+}
+
+func (t *fakeTimer) Stop() bool {
+    t.clock.mutex.Lock()
+    defer t.clock.mutex.Unlock()
+
+    return t.clock.remove(t)
+}
@@ -16,10 +16,14 @@ func main() {
         log.Fatal("Error loading .env file:", err)
     }
 
-    repository.ConnectToPostgreSQL(config.LoadConfig())
+    cfg := config.LoadConfig()
+    repository.ConnectToPostgreSQL(cfg)
+    if err := repository.Migrate(); err != nil {
+        log.Fatal("Error migrating database:", err)
+    }
     repository.ConnectMongoDB()
 
-    r := handlers.NewRouter()
+    r := handlers.NewRouter(cfg)
     corsHandler := middleware.CORSMiddleware(r)
 
     log.Println("Server running on http://localhost:8000")
@@ -3,6 +3,8 @@ package config
 import (
     "os"
     "log"
+    "strconv"
+    "time"
 )
 
 type Config struct {
@@ -12,17 +14,41 @@ type Config struct {
     DBPassword string
     DBName     string
     JWTSecret  string
+    TickRate   int // Game loop iterations per second
+    MatchSize  int // Players the matchmaker puts in one room
+    ReconnectGrace time.Duration // How long a dropped player keeps their seat
+    ChallengeAttempts int // Daily challenge attempts each player gets per day
+    ChallengeRollover time.Duration // Time of day (UTC) the daily challenge changes at
 }
 
 func LoadConfig() *Config {
-    return &Config{
+    cfg := &Config{
         DBHost:     getEnv("DB_HOST", "localhost"),
         DBPort:     getEnv("DB_PORT", "5432"),
         DBUser:     getEnv("DB_USER", "user"),
         DBPassword: getEnv("DB_PASSWORD", "password"),
         DBName:     getEnv("DB_NAME", "dbname"),
         JWTSecret:  getEnv("JWT_SECRET", "secret"),
+        TickRate:   getEnvInt("GAME_TICK_RATE", 20),
+        MatchSize:  getEnvInt("MATCH_SIZE", 4),
+        ReconnectGrace: time.Duration(getEnvInt("RECONNECT_GRACE_SECONDS", 15)) * time.Second,
+        ChallengeAttempts: getEnvInt("CHALLENGE_ATTEMPTS", 1),
+        ChallengeRollover: getEnvTimeOfDay("CHALLENGE_ROLLOVER", "00:00"),
     }
+
+    if cfg.TickRate < 1 {
+        log.Printf("GAME_TICK_RATE must be positive, using default value: 20")
+        cfg.TickRate = 20
+    }
+    if cfg.MatchSize < 2 {
+        log.Printf("MATCH_SIZE must be at least 2, using default value: 4")
+        cfg.MatchSize = 4
+    }
+    if cfg.ChallengeAttempts < 1 {
+        log.Printf("CHALLENGE_ATTEMPTS must be at least 1, using default value: 1")
+        cfg.ChallengeAttempts = 1
+    }
+    return cfg
 }
 
 // getEnv reads an environment variable and returns its value or a default value
@@ -34,3 +60,23 @@ func getEnv(key, defaultValue string) string {
     }
     return value
 }
+
+// getEnvInt reads an integer environment variable and falls back to the default if it's missing or invalid
+func getEnvInt(key string, defaultValue int) int {
+    value, err := strconv.Atoi(getEnv(key, strconv.Itoa(defaultValue)))
+    if err != nil {
+        log.Printf("Environment variable %s is not a valid integer, using default value: %d", key, defaultValue)
+        return defaultValue
+    }
+    return value
+}
+
+// getEnvTimeOfDay reads a HH:MM environment variable as the time since midnight and falls back to the default if it's missing or invalid
+func getEnvTimeOfDay(key, defaultValue string) time.Duration {
+    value, err := time.Parse("15:04", getEnv(key, defaultValue))
+    if err != nil {
+        log.Printf("Environment variable %s is not a valid HH:MM time, using default value: %s", key, defaultValue)
+        value, _ = time.Parse("15:04", defaultValue)
+    }
+    return time.Duration(value.Hour())*time.Hour + time.Duration(value.Minute())*time.Minute
+}
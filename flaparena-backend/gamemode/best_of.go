@@ -0,0 +1,49 @@
+package gamemode
+
+import "time"
+
+// bestOf plays last-bird-standing rounds until someone has won a majority
+// of them or the rounds run out. The room stays together in between.
+type bestOf struct {
+    rounds int
+}
+
+func (m bestOf) Info() Info {
+    return Info{Name: BestOf, Rounds: m.rounds}
+}
+
+func (bestOf) CheckPlayers(minPlayers, maxPlayers int) error {
+    return checkOpponents(BestOf, minPlayers)
+}
+
+func (bestOf) CanStart(readyPlayers, totalPlayers int) bool {
+    return canStart(readyPlayers, totalPlayers)
+}
+
+func (bestOf) PointsPerPipe() int {
+    return 1
+}
+
+func (bestOf) RoundOver(players []Player, elapsed time.Duration) bool {
+    return allDead(players)
+}
+
+func (bestOf) RoundWinners(players []Player) []string {
+    return lastAlive(players)
+}
+
+func (m bestOf) MatchOver(round int, players []Player) bool {
+    if round >= m.rounds {
+        return true
+    }
+    for _, player := range players {
+        if player.RoundWins > m.rounds/2 {
+            return true
+        }
+    }
+    return false
+}
+
+func (bestOf) MatchWinners(players []Player) []string {
+    return mostRoundWins(players)
+}
@@ -0,0 +1,39 @@
+package gamemode
+
+import "time"
+
+// lastBirdStanding is a single round that runs until every bird is down.
+// The bird that stayed up the longest wins.
+type lastBirdStanding struct{}
+
+func (lastBirdStanding) Info() Info {
+    return Info{Name: LastBirdStanding, Rounds: 1}
+}
+
+func (lastBirdStanding) CheckPlayers(minPlayers, maxPlayers int) error {
+    return checkOpponents(LastBirdStanding, minPlayers)
+}
+
+func (lastBirdStanding) CanStart(readyPlayers, totalPlayers int) bool {
+    return canStart(readyPlayers, totalPlayers)
+}
+
+func (lastBirdStanding) PointsPerPipe() int {
+    return 1
+}
+
+func (lastBirdStanding) RoundOver(players []Player, elapsed time.Duration) bool {
+    return allDead(players)
+}
+
+func (lastBirdStanding) RoundWinners(players []Player) []string {
+    return lastAlive(players)
+}
+
+func (lastBirdStanding) MatchOver(round int, players []Player) bool {
+    return true
+}
+
+func (lastBirdStanding) MatchWinners(players []Player) []string {
+    return mostRoundWins(players)
+}
@@ -0,0 +1,140 @@
+package gamemode
+
+import (
+    "fmt"
+    "time"
+)
+
+// Names of the built-in modes, as stored with rooms and games.
+const (
+    LastBirdStanding = "lastBirdStanding"
+    TimeAttack       = "timeAttack"
+    BestOf           = "bestOf"
+    DailyChallenge   = "dailyChallenge"
+    Practice         = "practice"
+)
+
+const (
+    defaultTimeLimit = 60 * time.Second
+    defaultRounds    = 3
+)
+
+// Player is what a mode gets to see of each player in the room.
+type Player struct {
+    UserID    string
+    Alive     bool
+    Score     int
+    DiedAt    int64 // simulation tick the bird died at, 0 while alive
+    RoundWins int
+}
+
+// Info describes a configured mode to clients and game records.
+type Info struct {
+    Name      string `json:"name" bson:"name"`
+    Rounds    int    `json:"rounds" bson:"rounds"`
+    TimeLimit int    `json:"timeLimit,omitempty" bson:"timeLimit,omitempty"` // seconds
+}
+
+// Mode decides how a room's games start, score, and end.
+type Mode interface {
+    Info() Info
+    // CheckPlayers reports why a room seating minPlayers to maxPlayers
+    // players can't play the mode, if it can't.
+    CheckPlayers(minPlayers, maxPlayers int) error
+    // CanStart reports whether a game can start with the given players once
+    // the room has the minimum its rules ask for.
+    CanStart(readyPlayers, totalPlayers int) bool
+    // PointsPerPipe is what clearing a single pipe is worth.
+    PointsPerPipe() int
+    // RoundOver reports whether the current round has finished.
+    RoundOver(players []Player, elapsed time.Duration) bool
+    // RoundWinners returns the user IDs that won a finished round.
+    RoundWinners(players []Player) []string
+    // MatchOver reports whether the match is decided after the given round,
+    // counted from 1. Until then the room plays another round.
+    MatchOver(round int, players []Player) bool
+    // MatchWinners returns the user IDs that won the finished match.
+    MatchWinners(players []Player) []string
+}
+
+// New creates the named mode. Zero rounds or time limit pick the defaults.
+func New(name string, rounds int, timeLimit time.Duration) (Mode, error) {
+    if rounds <= 0 {
+        rounds = defaultRounds
+    }
+    if timeLimit <= 0 {
+        timeLimit = defaultTimeLimit
+    }
+
+    switch name {
+    case "", LastBirdStanding:
+        return lastBirdStanding{}, nil
+    case TimeAttack:
+        return timeAttack{limit: timeLimit}, nil
+    case BestOf:
+        if rounds%2 == 0 {
+            return nil, fmt.Errorf("best-of needs an odd number of rounds, got %d", rounds)
+        }
+        return bestOf{rounds: rounds}, nil
+    case Practice:
+        return solo{name: Practice}, nil
+    default:
+        return nil, fmt.Errorf("unknown game mode %q", name)
+    }
+}
+
+// mostRoundWins returns the players with the most round wins.
+func mostRoundWins(players []Player) []string {
+    return topPlayers(players, func(p Player) int64 { return int64(p.RoundWins) })
+}
+
+func allDead(players []Player) bool {
+    for _, player := range players {
+        if player.Alive {
+            return false
+        }
+    }
+    return true
+}
+
+// lastAlive returns whoever stayed in the air the longest.
+func lastAlive(players []Player) []string {
+    return topPlayers(players, func(p Player) int64 {
+        if p.Alive {
+            return 1<<62
+        }
+        return p.DiedAt
+    })
+}
+
+func highestScore(players []Player) []string {
+    return topPlayers(players, func(p Player) int64 { return int64(p.Score) })
+}
+
+// topPlayers returns every player sharing the highest value of key.
+func topPlayers(players []Player, key func(Player) int64) []string {
+    var best int64
+    var winners []string
+    for _, player := range players {
+        value := key(player)
+        if len(winners) == 0 || value > best {
+            best = value
+            winners = []string{player.UserID}
+        } else if value == best {
+            winners = append(winners, player.UserID)
+        }
+    }
+    return winners
+}
+
+// checkOpponents is the player check of every mode flown against others.
+func checkOpponents(name string, minPlayers int) error {
+    if minPlayers < 2 {
+        return fmt.Errorf("%s needs minPlayers of at least 2", name)
+    }
+    return nil
+}
+
+func canStart(readyPlayers, totalPlayers int) bool {
+    return readyPlayers == totalPlayers
+}
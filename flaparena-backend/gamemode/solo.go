@@ -0,0 +1,54 @@
+package gamemode
+
+import (
+    "fmt"
+    "time"
+)
+
+// solo is a single player flying until they crash, scoring as many pipes
+// as they can. Practice rooms play it, and so does every daily challenge
+// attempt. Nobody wins a solo game.
+type solo struct {
+    name string
+}
+
+// NewDailyChallenge returns the mode challenge attempts are played in.
+// Rooms can't be created with it; the server opens one for each attempt.
+func NewDailyChallenge() Mode {
+    return solo{name: DailyChallenge}
+}
+
+func (m solo) Info() Info {
+    return Info{Name: m.name, Rounds: 1}
+}
+
+func (m solo) CheckPlayers(minPlayers, maxPlayers int) error {
+    if maxPlayers != 1 {
+        return fmt.Errorf("%s is played alone, maxPlayers must be 1", m.name)
+    }
+    return nil
+}
+
+func (solo) CanStart(readyPlayers, totalPlayers int) bool {
+    return canStart(readyPlayers, totalPlayers)
+}
+
+func (solo) PointsPerPipe() int {
+    return 1
+}
+
+func (solo) RoundOver(players []Player, elapsed time.Duration) bool {
+    return allDead(players)
+}
+
+func (solo) RoundWinners(players []Player) []string {
+    return nil
+}
+
+func (solo) MatchOver(round int, players []Player) bool {
+    return true
+}
+
+func (solo) MatchWinners(players []Player) []string {
+    return nil
+}
@@ -0,0 +1,41 @@
+package gamemode
+
+import "time"
+
+// timeAttack is a single round with a fixed duration. The highest score
+// when the timer runs out wins, even if that bird already crashed.
+type timeAttack struct {
+    limit time.Duration
+}
+
+func (m timeAttack) Info() Info {
+    return Info{Name: TimeAttack, Rounds: 1, TimeLimit: int(m.limit / time.Second)}
+}
+
+func (timeAttack) CheckPlayers(minPlayers, maxPlayers int) error {
+    return checkOpponents(TimeAttack, minPlayers)
+}
+
+func (timeAttack) CanStart(readyPlayers, totalPlayers int) bool {
+    return canStart(readyPlayers, totalPlayers)
+}
+
+func (timeAttack) PointsPerPipe() int {
+    return 1
+}
+
+func (m timeAttack) RoundOver(players []Player, elapsed time.Duration) bool {
+    return elapsed >= m.limit || allDead(players)
+}
+
+func (timeAttack) RoundWinners(players []Player) []string {
+    return highestScore(players)
+}
+
+func (timeAttack) MatchOver(round int, players []Player) bool {
+    return true
+}
+
+func (timeAttack) MatchWinners(players []Player) []string {
+    return mostRoundWins(players)
+}
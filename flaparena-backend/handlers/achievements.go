@@ -0,0 +1,89 @@
+package handlers
+
+import (
+    "log"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/achievement"
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+    "github.com/mapleleafu/flaparena/flaparena-backend/repository"
+)
+
+// awardAchievements checks the finished game's actions against every
+// achievement rule, stores what each player unlocked and tells them. Bots
+// and players who didn't see the game through don't earn any.
+func (room *Room) awardAchievements(gameID string, session *models.GameSession, results []models.GameResult) {
+    if gameID == "" || session == nil {
+        return
+    }
+
+    db := repository.PostgreSQLDB
+    for _, result := range results {
+        if result.Bot || result.Disconnected {
+            continue
+        }
+
+        var wins, games int
+        err := db.QueryRow("SELECT COUNT(*) FILTER (WHERE won), COUNT(*) FROM game_results WHERE "+userRecord, result.UserID).
+            Scan(&wins, &games)
+        if err != nil {
+            log.Printf("Error fetching totals of user %s: %v", result.UserID, err)
+            continue
+        }
+
+        facts := achievement.NewFacts(result, wins, games, session.Actions)
+        var unlocked []achievement.Rule
+        for _, rule := range achievement.Evaluate(facts) {
+            inserted, err := db.Exec(`INSERT INTO user_achievements (user_id, achievement_id, game_id) VALUES ($1, $2, $3)
+                ON CONFLICT DO NOTHING`, result.UserID, rule.ID, gameID)
+            if err != nil {
+                log.Printf("Error unlocking achievement %s for user %s: %v", rule.ID, result.UserID, err)
+                continue
+            }
+            if count, _ := inserted.RowsAffected(); count > 0 {
+                unlocked = append(unlocked, rule)
+            }
+        }
+        if len(unlocked) == 0 {
+            continue
+        }
+
+        log.Printf("User %s unlocked %d achievements in game %s", result.UserID, len(unlocked), gameID)
+        room.State.Mutex.Lock()
+        c, exists := room.members[result.UserID]
+        room.State.Mutex.Unlock()
+        if exists {
+            c.sendMessage("achievementsUnlocked", map[string]interface{}{
+                "gameID":       gameID,
+                "achievements": unlocked,
+            })
+        }
+    }
+}
+
+// loadAchievements returns the achievements a user has unlocked, latest first.
+func loadAchievements(userID string) ([]models.UnlockedAchievement, error) {
+    db := repository.PostgreSQLDB
+    rows, err := db.Query(`SELECT achievement_id, game_id, unlocked_at FROM user_achievements
+        WHERE user_id = $1 ORDER BY unlocked_at DESC, achievement_id`, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    unlocked := make([]models.UnlockedAchievement, 0)
+    for rows.Next() {
+        var entry models.UnlockedAchievement
+        if err := rows.Scan(&entry.ID, &entry.GameID, &entry.UnlockedAt); err != nil {
+            return nil, err
+        }
+        // Achievements since retired from the rules are no longer shown
+        rule, exists := achievement.Find(entry.ID)
+        if !exists {
+            continue
+        }
+        entry.Name = rule.Name
+        entry.Description = rule.Description
+        unlocked = append(unlocked, entry)
+    }
+    return unlocked, rows.Err()
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+    "encoding/json"
+    "fmt"
+    "log"
+    "math/rand"
+    "sort"
+    "sync/atomic"
+    "time"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+    "github.com/mapleleafu/flaparena/flaparena-backend/simulation"
+)
+
+// Bot difficulties, from the most forgiving to the hardest to beat.
+const (
+    BotEasy   = "easy"
+    BotMedium = "medium"
+    BotHard   = "hard"
+)
+
+// botSkill controls how well a bot plays.
+type botSkill struct {
+    // Delay between the bot deciding to flap and the flap arriving.
+    reaction time.Duration
+    // Chance that the bot misjudges and skips a flap it needed.
+    errorRate float64
+    // How far above the bottom of the gap the bot starts climbing.
+    margin float64
+}
+
+var botSkills = map[string]botSkill{
+    BotEasy:   {reaction: 250 * time.Millisecond, errorRate: 0.08, margin: 10},
+    BotMedium: {reaction: 120 * time.Millisecond, errorRate: 0.03, margin: 25},
+    BotHard:   {reaction: 40 * time.Millisecond, errorRate: 0.005, margin: 40},
+}
+
+// Bots get user IDs from a range real accounts never reach, so they can't
+// collide with a person in the game state or the stored game records.
+const botUserIDBase uint64 = 1 << 62
+
+var lastBotID uint64
+
+// bot is an AI player. It sits behind an ordinary Connection without a
+// socket and sends its actions through processMessage like anyone else.
+type bot struct {
+    conn   *Connection
+    skill  botSkill
+    random *rand.Rand
+
+    // When the flap the bot decided on arrives. Only touched by think.
+    flapAt time.Time
+}
+
+func newBot(difficulty string) (*bot, bool) {
+    skill, exists := botSkills[difficulty]
+    if !exists {
+        return nil, false
+    }
+
+    id := atomic.AddUint64(&lastBotID, 1)
+    conn := &Connection{
+        send:     make(chan []byte, 256),
+        userID:   botUserIDBase + id,
+        username: fmt.Sprintf("Bot %d (%s)", id, difficulty),
+        rating:   defaultRating,
+        bot:      true,
+    }
+    return &bot{
+        conn:   conn,
+        skill:  skill,
+        random: rand.New(rand.NewSource(time.Now().UnixNano() + int64(id))),
+    }, true
+}
+
+// run plays in room until the bot's connection is closed, thinking once a
+// tick on the room's scheduler so it takes turns with the room's other
+// timed work and stops along with it. Broadcasts are drained but otherwise
+// ignored; the bot reads the simulation directly.
+func (b *bot) run(room *Room) {
+    interval := time.Second / time.Duration(serverConfig.TickRate)
+    stopped := make(chan struct{})
+    var tick func()
+    tick = func() {
+        select {
+        case <-stopped:
+            return
+        default:
+        }
+        if b.conn.currentRoom() != room {
+            return
+        }
+        b.think(room, room.clock.Now())
+        room.scheduler.After(interval, tick)
+    }
+    room.scheduler.After(interval, tick)
+
+    for range b.conn.send {
+    }
+    close(stopped)
+}
+
+// think decides what the bot does in room at now.
+func (b *bot) think(room *Room, now time.Time) {
+    switch {
+    case !b.flapAt.IsZero() && !now.Before(b.flapAt):
+        b.flapAt = time.Time{}
+        b.act(room, "flap")
+    case !b.flapAt.IsZero():
+        // Still reacting to the last decision
+    case b.wantsToReady(room):
+        b.act(room, "ready")
+    case b.wantsToFlap(room) && b.random.Float64() >= b.skill.errorRate:
+        b.flapAt = now.Add(b.skill.reaction)
+    }
+}
+
+// act sends an action for the bot the same way a client would.
+func (b *bot) act(room *Room, action string) {
+    message, err := json.Marshal(models.GameActionMessage{Action: action, Timestamp: room.clock.Now().UnixMilli()})
+    if err != nil {
+        log.Printf("Error marshalling bot action: %v", err)
+        return
+    }
+    room.processMessage(b.conn, message)
+}
+
+// wantsToReady reports whether the bot is waiting in a room between games.
+func (b *bot) wantsToReady(room *Room) bool {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    player, exists := room.State.Players[b.conn.userIDString()]
+    return exists && !room.State.Started && !player.Ready
+}
+
+// wantsToFlap reports whether the bot's bird is falling towards the bottom
+// of the next gap.
+func (b *bot) wantsToFlap(room *Room) bool {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    if !room.State.Started || room.State.World == nil {
+        return false
+    }
+    userID := b.conn.userIDString()
+    bird, exists := room.State.World.Birds[userID]
+    if !exists || !bird.Alive || bird.Velocity < 0 {
+        return false
+    }
+    pipe, _, _ := room.State.World.NextPipe(userID)
+    lowest := pipe.GapTop + pipe.GapSize - simulation.BirdHitbox
+    return bird.Y > lowest-b.skill.margin
+}
+
+// leave takes the bot out of its room and stops it.
+func (b *bot) leave() {
+    b.conn.leaveRoom(false)
+    b.conn.close()
+}
+
+// handleAddBotAction seats a new bot in the room at the requested
+// difficulty. Only the room's owner can add bots.
+func (room *Room) handleAddBotAction(c *Connection, difficulty string) {
+    if !room.ownedBy(c.userIDString()) {
+        c.sendMessage("notRoomOwner", map[string]string{"roomID": room.ID})
+        return
+    }
+    if difficulty == "" {
+        difficulty = BotMedium
+    }
+    added, valid := newBot(difficulty)
+    if !valid {
+        c.sendMessage("invalidBotDifficulty", map[string]string{"difficulty": difficulty})
+        return
+    }
+
+    room.State.Mutex.Lock()
+    started := room.State.Started
+    full := len(room.State.Players) >= room.Rules.MaxPlayers
+    reserved := room.allowedPlayers != nil
+    room.State.Mutex.Unlock()
+
+    if reserved {
+        c.sendMessage("botsNotAllowed", map[string]string{"roomID": room.ID})
+        return
+    }
+    if started {
+        c.sendMessage("gameAlreadyStarted", map[string]string{"userID": c.userIDString()})
+        return
+    }
+    if full {
+        c.sendMessage("roomFull", map[string]string{"roomID": room.ID})
+        return
+    }
+
+    if !room.join(added.conn, false) {
+        return
+    }
+    room.State.Mutex.Lock()
+    room.bots[added.conn.userIDString()] = added
+    room.State.Mutex.Unlock()
+
+    log.Printf("User %d added %s bot %d to room %s", c.userID, difficulty, added.conn.userID, room.ID)
+    go added.run(room)
+}
+
+// handleRemoveBotAction takes a bot out of the room before a game starts.
+// Only the room's owner can remove bots.
+func (room *Room) handleRemoveBotAction(c *Connection, botID string) {
+    if !room.ownedBy(c.userIDString()) {
+        c.sendMessage("notRoomOwner", map[string]string{"roomID": room.ID})
+        return
+    }
+    room.State.Mutex.Lock()
+    removed, exists := room.bots[botID]
+    started := room.State.Started
+    room.State.Mutex.Unlock()
+
+    if !exists {
+        c.sendMessage("botNotFound", map[string]string{"userID": botID})
+        return
+    }
+    if started {
+        c.sendMessage("gameAlreadyStarted", map[string]string{"userID": c.userIDString()})
+        return
+    }
+
+    removed.leave()
+    log.Printf("User %d removed bot %s from room %s", c.userID, botID, room.ID)
+}
+
+// dismissBotsIfAlone sends every bot away once no person is left in the
+// room, so bots never keep a room alive or play on their own.
+func (room *Room) dismissBotsIfAlone() {
+    room.State.Mutex.Lock()
+    if len(room.bots) == 0 || len(room.State.Spectators) > 0 || len(room.waiting) > 0 {
+        room.State.Mutex.Unlock()
+        return
+    }
+    for _, player := range room.State.Players {
+        if !player.Bot {
+            room.State.Mutex.Unlock()
+            return
+        }
+    }
+    dismissed := make([]*bot, 0, len(room.bots))
+    for _, b := range room.bots {
+        dismissed = append(dismissed, b)
+    }
+    room.bots = make(map[string]*bot)
+    room.State.Mutex.Unlock()
+
+    for _, b := range dismissed {
+        b.leave()
+    }
+}
+
+// botIDs lists the bots playing in the room, for the game records.
+func (room *Room) botIDs() []string {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    ids := make([]string, 0, len(room.bots))
+    for userID := range room.bots {
+        ids = append(ids, userID)
+    }
+    sort.Strings(ids)
+    return ids
+}
@@ -0,0 +1,310 @@
+package handlers
+
+import (
+    "database/sql"
+    "hash/fnv"
+    "log"
+    "net/http"
+    "time"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/common"
+    "github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+    "github.com/mapleleafu/flaparena/flaparena-backend/repository"
+    "github.com/mapleleafu/flaparena/flaparena-backend/responses"
+    "github.com/mapleleafu/flaparena/flaparena-backend/utils"
+)
+
+// challengeAttempt is the daily challenge attempt a room was opened for.
+type challengeAttempt struct {
+    day     string
+    userID  string
+    attempt int
+    started bool // Set once its game began. Guarded by State.Mutex.
+}
+
+// challengeDay names the daily challenge running at t. Days change at the
+// configured rollover time rather than at midnight.
+func challengeDay(t time.Time) string {
+    return t.UTC().Add(-serverConfig.ChallengeRollover).Format("2006-01-02")
+}
+
+// nextRollover returns when the challenge running at t is replaced.
+func nextRollover(t time.Time) time.Time {
+    day, _ := time.Parse("2006-01-02", challengeDay(t))
+    return day.AddDate(0, 0, 1).Add(serverConfig.ChallengeRollover)
+}
+
+// challengeRules are the rules every attempt is played with.
+func challengeRules() models.RoomRules {
+    rules := models.DefaultRoomRules()
+    rules.MinPlayers = 1
+    rules.MaxPlayers = 1
+    return rules
+}
+
+// challengeSeed is the seed of a day's course, the same for everyone.
+func challengeSeed(day string) uint32 {
+    hash := fnv.New32a()
+    hash.Write([]byte("daily-challenge-" + day))
+    return hash.Sum32()
+}
+
+// GetDailyChallenge describes today's challenge and how the caller has done on it.
+func GetDailyChallenge(w http.ResponseWriter, r *http.Request) {
+    authInfo, ok := r.Context().Value(common.AuthInfoKey).(*models.CustomClaims)
+    if !ok {
+        utils.HandleError(w, responses.InternalServerError{Msg: "Error processing request."})
+        return
+    }
+
+    now := time.Now()
+    day := challengeDay(now)
+    challenge := models.DailyChallenge{
+        Day:          day,
+        Mode:         gamemode.NewDailyChallenge().Info(),
+        Rules:        challengeRules(),
+        Course:       challengeRules().CourseParams(challengeSeed(day)),
+        Attempts:     serverConfig.ChallengeAttempts,
+        NextRollover: nextRollover(now),
+    }
+
+    var best sql.NullInt64
+    db := repository.PostgreSQLDB
+    err := db.QueryRow(`SELECT COUNT(*), MAX(score) FILTER (WHERE finished_at IS NOT NULL)
+        FROM challenge_attempts WHERE day = $1 AND user_id = $2`, day, authInfo.ID).Scan(&challenge.AttemptsUsed, &best)
+    if err != nil {
+        log.Printf("Error fetching challenge attempts of user %s: %v", authInfo.ID, err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to fetch daily challenge."})
+        return
+    }
+    if best.Valid {
+        score := int(best.Int64)
+        challenge.BestScore = &score
+    }
+
+    utils.HandleSuccess(w, models.SuccessResponse(challenge))
+}
+
+// StartChallengeAttempt opens a room for one of the caller's attempts at
+// today's challenge. An attempt that was opened but not played yet is
+// handed out again instead of using up another one.
+func StartChallengeAttempt(w http.ResponseWriter, r *http.Request) {
+    authInfo, ok := r.Context().Value(common.AuthInfoKey).(*models.CustomClaims)
+    if !ok {
+        utils.HandleError(w, responses.InternalServerError{Msg: "Error processing request."})
+        return
+    }
+
+    day := challengeDay(time.Now())
+    db := repository.PostgreSQLDB
+
+    var attempt int
+    var roomID string
+    err := db.QueryRow("SELECT attempt, COALESCE(room_id, '') FROM challenge_attempts WHERE day = $1 AND user_id = $2 AND started_at IS NULL",
+        day, authInfo.ID).Scan(&attempt, &roomID)
+    if err == nil {
+        if room, exists := rooms.Get(roomID); exists && !room.challengeStarted() {
+            writeChallengeAttempt(w, day, attempt, room, authInfo.ID)
+            return
+        }
+        // The room is gone, say after a restart, so the attempt was never played
+        if _, err := db.Exec("DELETE FROM challenge_attempts WHERE day = $1 AND user_id = $2 AND attempt = $3 AND started_at IS NULL",
+            day, authInfo.ID, attempt); err != nil {
+            log.Printf("Error releasing challenge attempt of user %s: %v", authInfo.ID, err)
+            utils.HandleError(w, responses.InternalServerError{Msg: "Failed to start challenge attempt."})
+            return
+        }
+    } else if err != sql.ErrNoRows {
+        log.Printf("Error fetching challenge attempts of user %s: %v", authInfo.ID, err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to start challenge attempt."})
+        return
+    }
+
+    attempt, err = claimChallengeAttempt(day, authInfo.ID)
+    if err == sql.ErrNoRows {
+        utils.HandleError(w, responses.ForbiddenError{Msg: "No daily challenge attempts left today."})
+        return
+    } else if err != nil {
+        log.Printf("Error claiming challenge attempt for user %s: %v", authInfo.ID, err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to start challenge attempt."})
+        return
+    }
+
+    room, err := rooms.CreatePrivate(authInfo.ID, gamemode.NewDailyChallenge(), challengeRules())
+    if err == nil {
+        room.holdChallengeAttempt(&challengeAttempt{day: day, userID: authInfo.ID, attempt: attempt})
+        _, err = db.Exec("UPDATE challenge_attempts SET room_id = $1, invite_code = $2 WHERE day = $3 AND user_id = $4 AND attempt = $5",
+            room.ID, room.InviteCode, day, authInfo.ID, attempt)
+    }
+    if err != nil {
+        log.Printf("Error opening challenge attempt for user %s: %v", authInfo.ID, err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to start challenge attempt."})
+        return
+    }
+
+    writeChallengeAttempt(w, day, attempt, room, authInfo.ID)
+}
+
+// claimChallengeAttempt takes the user's next attempt at the day's challenge.
+// It returns sql.ErrNoRows once the user has no attempts left.
+func claimChallengeAttempt(day, userID string) (int, error) {
+    tx, err := repository.PostgreSQLDB.Begin()
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback()
+
+    // Claims of the same user on the same day wait for each other, so each
+    // one counts every claim made before it
+    if _, err = tx.Exec("SELECT pg_advisory_xact_lock(hashtext($1))", "challenge:"+day+":"+userID); err != nil {
+        return 0, err
+    }
+
+    var attempt int
+    err = tx.QueryRow(`INSERT INTO challenge_attempts (day, user_id, attempt)
+        SELECT $1, $2, COALESCE(MAX(attempt), 0) + 1 FROM challenge_attempts WHERE day = $1 AND user_id = $2
+        HAVING COUNT(*) < $3 RETURNING attempt`, day, userID, serverConfig.ChallengeAttempts).Scan(&attempt)
+    if err != nil {
+        return 0, err
+    }
+    return attempt, tx.Commit()
+}
+
+func writeChallengeAttempt(w http.ResponseWriter, day string, attempt int, room *Room, userID string) {
+    var claimed int
+    err := repository.PostgreSQLDB.QueryRow("SELECT COUNT(*) FROM challenge_attempts WHERE day = $1 AND user_id = $2", day, userID).Scan(&claimed)
+    if err != nil {
+        log.Printf("Error counting challenge attempts of user %s: %v", userID, err)
+    }
+
+    utils.HandleSuccess(w, models.SuccessResponse(models.ChallengeAttempt{
+        Day:          day,
+        Attempt:      attempt,
+        RoomID:       room.ID,
+        InviteCode:   room.InviteCode,
+        AttemptsLeft: serverConfig.ChallengeAttempts - claimed,
+    }))
+}
+
+// FetchChallengeLeaderboard ranks everyone's best finished attempt at the
+// challenge of ?day= (today by default), paged like FetchLeaderboard.
+func FetchChallengeLeaderboard(w http.ResponseWriter, r *http.Request) {
+    authInfo, ok := r.Context().Value(common.AuthInfoKey).(*models.CustomClaims)
+    if !ok {
+        utils.HandleError(w, responses.InternalServerError{Msg: "Error processing request."})
+        return
+    }
+
+    board := models.ChallengeLeaderboard{Day: r.URL.Query().Get("day")}
+    if board.Day == "" {
+        board.Day = challengeDay(time.Now())
+    } else if _, err := time.Parse("2006-01-02", board.Day); err != nil {
+        utils.HandleError(w, responses.BadRequestError{Msg: "day must look like 2026-10-18."})
+        return
+    }
+
+    limit, err := pageLimit(r)
+    if err != nil {
+        utils.HandleError(w, responses.BadRequestError{Msg: err.Error()})
+        return
+    }
+
+    totals := `SELECT user_id, MAX(score)::float8 AS value, COUNT(*) AS games FROM challenge_attempts
+        WHERE day = $1 AND finished_at IS NOT NULL GROUP BY user_id`
+    board.Entries, board.NextCursor, board.Me, err = loadRanking(totals, []interface{}{board.Day}, r.URL.Query().Get("cursor"), limit, authInfo.ID)
+    if err == errInvalidCursor {
+        utils.HandleError(w, responses.BadRequestError{Msg: "Invalid cursor."})
+        return
+    } else if err != nil {
+        log.Printf("Error fetching challenge leaderboard: %v", err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to fetch leaderboard."})
+        return
+    }
+
+    utils.HandleSuccess(w, models.SuccessResponse(board))
+}
+
+// holdChallengeAttempt reserves the room for a single challenge attempt.
+func (room *Room) holdChallengeAttempt(attempt *challengeAttempt) {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    room.challenge = attempt
+    room.allowedPlayers = map[string]bool{attempt.userID: true}
+}
+
+// challengeStarted reports whether the room's challenge attempt has been played.
+func (room *Room) challengeStarted() bool {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    return room.challenge != nil && room.challenge.started
+}
+
+// startChallengeAttempt marks the room's attempt as used once its game begins.
+func (room *Room) startChallengeAttempt() {
+    room.State.Mutex.Lock()
+    attempt := room.challenge
+    if attempt != nil {
+        attempt.started = true
+    }
+    room.State.Mutex.Unlock()
+    if attempt == nil {
+        return
+    }
+
+    _, err := repository.PostgreSQLDB.Exec("UPDATE challenge_attempts SET started_at = NOW() WHERE day = $1 AND user_id = $2 AND attempt = $3",
+        attempt.day, attempt.userID, attempt.attempt)
+    if err != nil {
+        log.Printf("Error starting challenge attempt of user %s: %v", attempt.userID, err)
+    }
+}
+
+// reportChallengeResult records the score of the room's finished attempt
+// for the day's leaderboard.
+func (room *Room) reportChallengeResult(gameID string, results []models.GameResult) {
+    room.State.Mutex.Lock()
+    attempt := room.challenge
+    room.State.Mutex.Unlock()
+    if attempt == nil || gameID == "" {
+        return
+    }
+
+    score := 0
+    for _, result := range results {
+        if result.UserID == attempt.userID {
+            score = result.Score
+        }
+    }
+
+    _, err := repository.PostgreSQLDB.Exec(`UPDATE challenge_attempts SET game_id = $1, score = $2, finished_at = NOW()
+        WHERE day = $3 AND user_id = $4 AND attempt = $5`, gameID, score, attempt.day, attempt.userID, attempt.attempt)
+    if err != nil {
+        log.Printf("Error recording challenge attempt of user %s: %v", attempt.userID, err)
+        return
+    }
+    room.broadcastMessage("challengeAttemptEnd", map[string]interface{}{
+        "day":     attempt.day,
+        "attempt": attempt.attempt,
+        "score":   score,
+    })
+}
+
+// releaseChallengeAttempt gives back an attempt whose room closed before it
+// was played.
+func (room *Room) releaseChallengeAttempt() {
+    room.State.Mutex.Lock()
+    attempt := room.challenge
+    unplayed := attempt != nil && !attempt.started
+    room.State.Mutex.Unlock()
+    if !unplayed {
+        return
+    }
+
+    _, err := repository.PostgreSQLDB.Exec("DELETE FROM challenge_attempts WHERE day = $1 AND user_id = $2 AND attempt = $3 AND started_at IS NULL",
+        attempt.day, attempt.userID, attempt.attempt)
+    if err != nil {
+        log.Printf("Error releasing challenge attempt of user %s: %v", attempt.userID, err)
+    }
+}
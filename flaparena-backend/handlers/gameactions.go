@@ -7,7 +7,7 @@ import (
 	"github.com/mapleleafu/flaparena/flaparena-backend/models"
 )
 
-func processMessage(c *Connection, rawMessage []byte) {
+func (room *Room) processMessage(c *Connection, rawMessage []byte) {
     var gameActionMsg models.GameActionMessage
     err := json.Unmarshal(rawMessage, &gameActionMsg)
     if err != nil {
@@ -16,133 +16,208 @@ func processMessage(c *Connection, rawMessage []byte) {
     }
 
     userIDStr := strconv.FormatUint(c.userID, 10)
+    room.touch(userIDStr)
     gameAction := models.GameAction{
         UserID:    userIDStr,
         Action:    gameActionMsg.Action,
         Timestamp: gameActionMsg.Timestamp,
     }
 
+    // Spectators and the waiting list watch everything but can't touch the game
+    if !c.isPlayer() {
+        switch gameAction.Action {
+            case "ready", "unready", "flap", "score", "dead", "addBot", "removeBot":
+                c.sendMessage("spectatorCannotAct", map[string]string{"userID": userIDStr})
+                return
+        }
+    }
+
     switch gameAction.Action {
         case "ready":
-            handleReadyAction(gameAction)
+            room.handleReadyAction(gameAction)
+        case "unready":
+            room.handleUnreadyAction(gameAction)
         case "flap":
-            handleFlapAction(gameAction)
+            room.handleFlapAction(gameAction)
         case "score":
-            handleScoreAction(gameAction)
+            room.handleScoreAction(gameAction)
         case "dead":
-            handleDeadAction(gameAction)
+            room.handleDeadAction(gameAction)
+        case "play":
+            if room.switchRole(c, rolePlayer) {
+                room.notifyWaiting()
+                room.broadcastGameState()
+            }
+        case "spectate":
+            room.cancelCountdown(userIDStr)
+            if room.switchRole(c, roleSpectator) {
+                room.promoteWaiting()
+                room.broadcastGameState()
+                room.startGame()
+            }
+        case "queue":
+            room.handleQueueAction(c)
+        case "addBot":
+            room.handleAddBotAction(c, gameActionMsg.Difficulty)
+        case "removeBot":
+            room.handleRemoveBotAction(c, gameActionMsg.Target)
         case "info":
-            broadcastGameState()
+            if started, _ := room.gameStatus(userIDStr); started {
+                room.broadcastSnapshot()
+            } else {
+                room.broadcastGameState()
+            }
         default:
             log.Printf("Unhandled game action: %s", gameAction.Action)
     }
 }
 
-func handleGameAction(action models.GameAction, gameID string) {
-    // Safely access the gameSessions map.
-    gameSessionsMutex.Lock()
-    defer gameSessionsMutex.Unlock()
+func (room *Room) handleGameAction(action models.GameAction) {
+    room.sessionMutex.Lock()
+    defer room.sessionMutex.Unlock()
 
-    // Initialize the game session in the map if it doesn't exist.
-    if _, exists := gameSessions[gameID]; !exists {
-        gameSessions[gameID] = &models.GameSession{}
+    // Actions that arrive outside of a running game have nowhere to go.
+    if room.session == nil {
+        return
     }
 
     // Add the action to the session.
-    gameSessions[gameID].Actions = append(gameSessions[gameID].Actions, action)
+    room.session.Actions = append(room.session.Actions, action)
+}
+
+// handleLobbyAction records a server action taken between games. With no
+// session to add it to, it is held for the next game played in the room.
+func (room *Room) handleLobbyAction(action models.GameAction) {
+    room.sessionMutex.Lock()
+    defer room.sessionMutex.Unlock()
+
+    if room.session != nil {
+        room.session.Actions = append(room.session.Actions, action)
+        return
+    }
+    room.lobbyActions = append(room.lobbyActions, action)
 }
 
-func broadcastMessage(messageType string, data interface{}) {
-    message, err := json.Marshal(struct {
-        Type string      `json:"type"`
-        Data interface{} `json:"data"`
-    }{
-        Type: messageType,
-        Data: data,
-    })
+func (room *Room) broadcastMessage(messageType string, data interface{}) {
+    message, err := encodeMessage(messageType, data)
     if err != nil {
         log.Printf("Error marshalling broadcast message: %v", err)
         return
     }
-    hub.broadcast <- message
+    room.hub.Broadcast(message)
 }
 
-func handleReadyAction(action models.GameAction) {
-    playerState, exists := currentGameState.Players[action.UserID]
-
-    if !currentGameState.Started {
-        if exists && !playerState.Ready {
-            // Update the player's ready state
-            playerState.Ready = true
-            playerState.Alive = true
-
-            log.Printf("Player %s is ready", action.UserID)
-            broadcastGameState()
-            startGame()
-        } else if !exists {
-            // Create a new player state
-            currentGameState.Players[action.UserID] = &models.PlayerState{
-                UserID:   action.UserID,
-                Ready:    true,
-                Alive:    true,
-                Score:    0,
-            }
+// gameStatus reports whether a game is running in the room and whether
+// userID is one of its players.
+func (room *Room) gameStatus(userID string) (started bool, playing bool) {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
 
-            log.Printf("Player %s is ready", action.UserID)
-            broadcastGameState()
-            startGame()
-        } else {
-            broadcastMessage("playerAlreadyReady", map[string]string{"userID": action.UserID})
-        }
-    } else {
-        broadcastMessage("gameAlreadyStarted", map[string]string{"userID": action.UserID})
+    _, playing = room.State.Players[userID]
+    return room.State.Started, playing
+}
+
+func (room *Room) handleReadyAction(action models.GameAction) {
+    room.State.Mutex.Lock()
+    if room.State.Started {
+        room.State.Mutex.Unlock()
+        room.broadcastMessage("gameAlreadyStarted", map[string]string{"userID": action.UserID})
+        return
+    }
+    // Only seated players can ready up; everyone else joins through the
+    // room's cap and waiting list first
+    playerState, exists := room.State.Players[action.UserID]
+    if !exists {
+        room.State.Mutex.Unlock()
+        room.broadcastMessage("playerNotFound", map[string]string{"userID": action.UserID})
+        return
+    }
+    if playerState.Ready {
+        room.State.Mutex.Unlock()
+        room.broadcastMessage("playerAlreadyReady", map[string]string{"userID": action.UserID})
+        return
     }
+    // Update the player's ready state
+    playerState.Ready = true
+    playerState.Alive = true
+    room.State.Mutex.Unlock()
+
+    log.Printf("Player %s is ready", action.UserID)
+    room.broadcastGameState()
+    room.startGame()
 }
 
-func handleFlapAction(action models.GameAction) {
-    if currentGameState.Started {
-        if _, exists := currentGameState.Players[action.UserID]; exists && currentGameState.Players[action.UserID].Alive{
-                broadcastMessage("playerAction", map[string]interface{}{"action": "flap", "userID": action.UserID})
-                handleGameAction(action, currentGameState.GameID)
+// handleUnreadyAction takes back a ready, aborting the countdown if it has
+// already begun.
+func (room *Room) handleUnreadyAction(action models.GameAction) {
+    room.State.Mutex.Lock()
+    if room.State.Started {
+        room.State.Mutex.Unlock()
+        room.broadcastMessage("gameAlreadyStarted", map[string]string{"userID": action.UserID})
+        return
+    }
+    playerState, exists := room.State.Players[action.UserID]
+    if !exists || !playerState.Ready {
+        room.State.Mutex.Unlock()
+        room.broadcastMessage("playerNotReady", map[string]string{"userID": action.UserID})
+        return
+    }
+    playerState.Ready = false
+    playerState.Alive = false
+    cancelled := room.stopCountdown()
+    room.State.Mutex.Unlock()
+
+    log.Printf("Player %s is no longer ready", action.UserID)
+    if cancelled {
+        room.broadcastMessage("countdownCancelled", map[string]string{"userID": action.UserID})
+    }
+    room.broadcastGameState()
+}
+
+func (room *Room) handleFlapAction(action models.GameAction) {
+    if started, exists := room.gameStatus(action.UserID); started {
+        if exists && room.simulateFlap(action.UserID) {
+                room.broadcastMessage("playerAction", map[string]interface{}{"action": "flap", "userID": action.UserID})
+                // Recorded at server time like every other action in the
+                // session, whatever the client's clock says
+                action.Timestamp = room.clock.Now().UnixMilli()
+                room.handleGameAction(action)
                 log.Printf("Player %s flapped", action.UserID)
         } else {
-            broadcastMessage("playerNotFound", map[string]string{"userID": action.UserID})
+            room.broadcastMessage("playerNotFound", map[string]string{"userID": action.UserID})
         }
     } else {
-        broadcastMessage("gameNotStarted", map[string]string{"userID": action.UserID})
+        room.broadcastMessage("gameNotStarted", map[string]string{"userID": action.UserID})
     }
 }
 
-func handleScoreAction(action models.GameAction) {
-    if currentGameState.Started {
-        if _, exists := currentGameState.Players[action.UserID]; exists && currentGameState.Players[action.UserID].Alive{
-            playerScored(action.UserID)
-            broadcastMessage("playerScored", map[string]interface{}{"userID": action.UserID})
-            handleGameAction(action, currentGameState.GameID)
-            log.Printf("Player %s scored", action.UserID)
+// handleScoreAction treats the client's score claim only as a prompt to
+// catch the simulation up; points are awarded by the simulation itself.
+func (room *Room) handleScoreAction(action models.GameAction) {
+    if started, exists := room.gameStatus(action.UserID); started {
+        if exists {
+            room.syncSimulation()
         } else {
-            broadcastMessage("playerNotFound", map[string]string{"userID": action.UserID})
+            room.broadcastMessage("playerNotFound", map[string]string{"userID": action.UserID})
         }
     } else {
-        broadcastMessage("gameNotStarted", map[string]string{"userID": action.UserID})
+        room.broadcastMessage("gameNotStarted", map[string]string{"userID": action.UserID})
     }
 }
 
-func handleDeadAction(action models.GameAction) {
-    if currentGameState.Started {
-        if player, exists := currentGameState.Players[action.UserID]; exists && player.Alive{
-            player.Alive = false
-            broadcastMessage("playerDead", map[string]string{"userID": action.UserID})
-            handleGameAction(action, currentGameState.GameID)
-            log.Printf("Player %s is dead", action.UserID)
-
-            if checkAllPlayersDead() {
-                endGame()
+// handleDeadAction works like handleScoreAction: a bird only dies when the
+// simulation says it collided.
+func (room *Room) handleDeadAction(action models.GameAction) {
+    if started, exists := room.gameStatus(action.UserID); started {
+        if exists {
+            room.syncSimulation()
+            if room.isAlive(action.UserID) {
+                log.Printf("Player %s claimed death but is still alive in the simulation", action.UserID)
             }
         } else {
-            broadcastMessage("playerNotFound", map[string]string{"userID": action.UserID})
+            room.broadcastMessage("playerNotFound", map[string]string{"userID": action.UserID})
         }
     } else {
-        broadcastMessage("gameNotStarted", map[string]string{"userID": action.UserID})
+        room.broadcastMessage("gameNotStarted", map[string]string{"userID": action.UserID})
     }
 }
@@ -3,7 +3,10 @@ package handlers
 import (
 	"net/http"
     "context"
+    "fmt"
     "log"
+    "strings"
+    "time"
     "github.com/gorilla/mux"
     "go.mongodb.org/mongo-driver/bson/primitive"
 
@@ -17,6 +20,18 @@ import (
 	"github.com/mapleleafu/flaparena/flaparena-backend/utils"
 )
 
+// Orders game history can be sorted in.
+const (
+    sortNewest = "newest"
+    sortOldest = "oldest"
+    sortScore  = "score" // Highest score first
+)
+
+// FetchUserGames returns the caller's finished games a page at a time,
+// with ?limit= and the returned next_cursor. They can be narrowed down to
+// games finished ?from= and ?to= (RFC 3339 times or whole days such as
+// 2026-10-18), of a ?mode=, against an ?opponent= user ID, or with a
+// ?result= of won or lost, and sorted with ?sort= newest, oldest or score.
 func FetchUserGames(w http.ResponseWriter, r *http.Request) {
     authInfo, ok := r.Context().Value(common.AuthInfoKey).(*models.CustomClaims)
     if !ok {
@@ -25,11 +40,90 @@ func FetchUserGames(w http.ResponseWriter, r *http.Request) {
     }
     
     userID := authInfo.ID
-    db := repository.PostgreSQLDB
+    query := r.URL.Query()
+
+    limit, err := pageLimit(r)
+    if err != nil {
+        utils.HandleError(w, responses.BadRequestError{Msg: err.Error()})
+        return
+    }
 
-    var games []models.Game
-    query := "SELECT id, created_at, finished_at, user_ids FROM games WHERE $1 = ANY(user_ids) ORDER BY created_at DESC"
-    rows, err := db.Query(query, userID)
+    args := []interface{}{userID}
+    conditions := []string{"$1 = ANY(g.user_ids)", "g.finished_at IS NOT NULL"}
+    addCondition := func(condition string, value interface{}) {
+        args = append(args, value)
+        conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+    }
+
+    if value := query.Get("from"); value != "" {
+        from, err := parseHistoryTime(value, false)
+        if err != nil {
+            utils.HandleError(w, responses.BadRequestError{Msg: "Invalid from time."})
+            return
+        }
+        addCondition("g.finished_at >= $%d", from)
+    }
+    if value := query.Get("to"); value != "" {
+        to, err := parseHistoryTime(value, true)
+        if err != nil {
+            utils.HandleError(w, responses.BadRequestError{Msg: "Invalid to time."})
+            return
+        }
+        addCondition("g.finished_at < $%d", to)
+    }
+    if mode := query.Get("mode"); mode != "" {
+        addCondition("g.mode = $%d", mode)
+    }
+    if opponent := query.Get("opponent"); opponent != "" {
+        addCondition("$%d = ANY(g.user_ids)", opponent)
+    }
+    switch query.Get("result") {
+    case "":
+    case "won":
+        conditions = append(conditions, "r.won")
+    case "lost":
+        // Games without a result row show as lost, so they filter as lost too
+        conditions = append(conditions, "NOT COALESCE(r.won, FALSE)")
+    default:
+        utils.HandleError(w, responses.BadRequestError{Msg: "result must be won or lost."})
+        return
+    }
+
+    // Every order breaks ties on the game ID so the cursor is exact
+    var key, direction, before string
+    var keyOf func(models.Game) float64
+    switch query.Get("sort") {
+    case "", sortNewest:
+        key, direction, before = "(EXTRACT(EPOCH FROM g.finished_at) * 1000000)::bigint", "DESC", "<"
+        keyOf = func(game models.Game) float64 { return float64(game.FinishedAt.UnixMicro()) }
+    case sortOldest:
+        key, direction, before = "(EXTRACT(EPOCH FROM g.finished_at) * 1000000)::bigint", "ASC", ">"
+        keyOf = func(game models.Game) float64 { return float64(game.FinishedAt.UnixMicro()) }
+    case sortScore:
+        key, direction, before = "COALESCE(r.score, 0)", "DESC", "<"
+        keyOf = func(game models.Game) float64 { return float64(game.Score) }
+    default:
+        utils.HandleError(w, responses.BadRequestError{Msg: "sort must be newest, oldest or score."})
+        return
+    }
+    if cursor := query.Get("cursor"); cursor != "" {
+        value, gameID, err := decodeCursor(cursor)
+        if err != nil {
+            utils.HandleError(w, responses.BadRequestError{Msg: "Invalid cursor."})
+            return
+        }
+        args = append(args, int64(value), gameID)
+        conditions = append(conditions, fmt.Sprintf("(%[1]s %[2]s $%[3]d OR (%[1]s = $%[3]d AND g.id %[2]s $%[4]d))",
+            key, before, len(args)-1, len(args)))
+    }
+    args = append(args, limit+1)
+
+    db := repository.PostgreSQLDB
+    rows, err := db.Query(`SELECT g.id, g.created_at, g.finished_at, g.user_ids, g.mode, g.rules, g.bot_ids,
+            COALESCE(r.score, 0), COALESCE(r.placement, 0), COALESCE(r.won, FALSE)
+        FROM games g LEFT JOIN game_results r ON r.game_id = g.id AND r.user_id = $1
+        WHERE `+strings.Join(conditions, " AND ")+`
+        ORDER BY `+key+` `+direction+`, g.id `+direction+fmt.Sprintf(" LIMIT $%d", len(args)), args...)
 
     if err != nil {
         log.Printf("Error fetching games: %v", err)
@@ -38,14 +132,16 @@ func FetchUserGames(w http.ResponseWriter, r *http.Request) {
     }
     defer rows.Close()
 
+    page := models.GamePage{Games: make([]models.Game, 0, limit)}
     for rows.Next() {
         var game models.Game
-        err := rows.Scan(&game.ID, &game.CreatedAt, &game.FinishedAt, pq.Array(&game.UserIDs))
+        err := rows.Scan(&game.ID, &game.CreatedAt, &game.FinishedAt, pq.Array(&game.UserIDs), &game.Mode, &game.Rules, pq.Array(&game.BotIDs),
+            &game.Score, &game.Placement, &game.Won)
         if err != nil {
             utils.HandleError(w, responses.InternalServerError{Msg: "Error processing user games."})
             return
         }
-        games = append(games, game)
+        page.Games = append(page.Games, game)
     }
 
     if err = rows.Err(); err != nil {
@@ -54,13 +150,65 @@ func FetchUserGames(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    if len(games) == 0 {
-        log.Printf("No games found for user %s", userID)
-        utils.HandleSuccess(w, models.SuccessResponse(models.Game{})) // Return an empty array for consistency
+    if len(page.Games) > limit {
+        page.Games = page.Games[:limit]
+        last := page.Games[limit-1]
+        page.NextCursor = encodeCursor(keyOf(last), last.ID)
+    }
+
+    if err = attachGameResults(page.Games); err != nil {
+        log.Printf("Error fetching game results: %v", err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to fetch user games."})
         return
     }
 
-    utils.HandleSuccess(w, models.SuccessResponse(games))
+    utils.HandleSuccess(w, models.SuccessResponse(page))
+}
+
+// parseHistoryTime reads a time filter. A whole day stands for its start,
+// or when end is set for the start of the next day.
+func parseHistoryTime(value string, end bool) (time.Time, error) {
+    if day, err := time.Parse("2006-01-02", value); err == nil {
+        if end {
+            day = day.AddDate(0, 0, 1)
+        }
+        return day, nil
+    }
+    return time.Parse(time.RFC3339, value)
+}
+
+// attachGameResults fills in the per-player results of every game.
+func attachGameResults(games []models.Game) error {
+    if len(games) == 0 {
+        return nil
+    }
+    index := make(map[string]int, len(games))
+    gameIDs := make([]string, len(games))
+    for i, game := range games {
+        index[game.ID] = i
+        gameIDs[i] = game.ID
+        games[i].Results = []models.GameResult{}
+    }
+
+    db := repository.PostgreSQLDB
+    rows, err := db.Query(`SELECT game_id, user_id, mode, score, placement, time_alive_ms, flaps, won, bot, disconnected, finished_at
+        FROM game_results WHERE game_id = ANY($1) ORDER BY placement, user_id`, pq.Array(gameIDs))
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var result models.GameResult
+        err := rows.Scan(&result.GameID, &result.UserID, &result.Mode, &result.Score, &result.Placement,
+            &result.TimeAlive, &result.Flaps, &result.Won, &result.Bot, &result.Disconnected, &result.FinishedAt)
+        if err != nil {
+            return err
+        }
+        i := index[result.GameID]
+        games[i].Results = append(games[i].Results, result)
+    }
+    return rows.Err()
 }
 
 func FetchGameActions(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+    "sort"
+    "time"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/clock"
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+)
+
+// gameLoop is the ticker driving the current round.
+type gameLoop struct {
+    interval time.Duration
+    timer    clock.Timer
+}
+
+// startGameLoop starts ticking the current game on the room's scheduler.
+func (room *Room) startGameLoop() {
+    loop := &gameLoop{interval: time.Second / time.Duration(serverConfig.TickRate)}
+
+    room.State.Mutex.Lock()
+    room.loop = loop
+    loop.timer = room.scheduler.After(loop.interval, func() { room.gameLoopTick(loop) })
+    room.State.Mutex.Unlock()
+}
+
+// stopGameLoop stops the game loop if one is running. It is safe to call
+// from the loop itself.
+func (room *Room) stopGameLoop() {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    if room.loop != nil {
+        room.loop.timer.Stop()
+        room.loop = nil
+    }
+}
+
+// gameLoopTick advances the simulation, which resolves deaths and time
+// limits even when nobody is sending input, broadcasts a snapshot and
+// schedules the next tick.
+func (room *Room) gameLoopTick(loop *gameLoop) {
+    // The loop may have been stopped, possibly by the previous tick
+    room.State.Mutex.Lock()
+    if room.loop != loop {
+        room.State.Mutex.Unlock()
+        return
+    }
+    loop.timer = room.scheduler.After(loop.interval, func() { room.gameLoopTick(loop) })
+    room.State.Mutex.Unlock()
+
+    room.syncSimulation()
+    room.killIdlePlayers()
+    room.checkRoundOver()
+    room.broadcastSnapshot()
+}
+
+// broadcastSnapshot sends the position, alive flag and score of every bird.
+func (room *Room) broadcastSnapshot() {
+    room.State.Mutex.Lock()
+    if room.State.World == nil {
+        room.State.Mutex.Unlock()
+        return
+    }
+
+    snapshot := models.Snapshot{
+        Tick:    room.State.World.Tick,
+        Players: make([]models.PlayerSnapshot, 0, len(room.State.World.Birds)),
+    }
+    for userID, bird := range room.State.World.Birds {
+        // Points are awarded by the room's mode, so report the player's score
+        score := bird.Score
+        if player, exists := room.State.Players[userID]; exists {
+            score = player.Score
+        }
+        snapshot.Players = append(snapshot.Players, models.PlayerSnapshot{
+            UserID:   userID,
+            Y:        bird.Y,
+            Velocity: bird.Velocity,
+            Alive:    bird.Alive,
+            Score:    score,
+        })
+    }
+    room.State.Mutex.Unlock()
+
+    sort.Slice(snapshot.Players, func(i, j int) bool {
+        return snapshot.Players[i].UserID < snapshot.Players[j].UserID
+    })
+    room.broadcastMessage("snapshot", snapshot)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+    "sort"
+    "time"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+    "github.com/mapleleafu/flaparena/flaparena-backend/simulation"
+)
+
+// settleRound closes the books on the round just played: its winners each
+// get a round win and everyone's match totals are updated. It returns the
+// round's winners. Callers must hold State.Mutex.
+func (room *Room) settleRound() []string {
+    winners := room.Mode.RoundWinners(room.modePlayers())
+    for _, userID := range winners {
+        room.State.Players[userID].RoundWins++
+    }
+    room.creditRound()
+    return winners
+}
+
+// creditRound adds the round just played to every ready player's match
+// totals. Callers must hold State.Mutex.
+func (room *Room) creditRound() {
+    for _, player := range room.State.Players {
+        if player.Ready {
+            room.creditPlayer(player)
+        }
+    }
+}
+
+// creditPlayer adds the current round to a single player's match totals.
+// Callers must hold State.Mutex.
+func (room *Room) creditPlayer(player *models.PlayerState) {
+    if player.Score > player.BestScore {
+        player.BestScore = player.Score
+    }
+    if player.DiedAt > 0 {
+        player.TicksAlive += player.DiedAt
+    } else if room.State.World != nil {
+        player.TicksAlive += room.State.World.Tick
+    }
+}
+
+// keepResult holds on to a player leaving the running game so that they
+// still get a result when it ends. Callers must hold State.Mutex.
+func (room *Room) keepResult(userID string) {
+    player, exists := room.State.Players[userID]
+    if !exists || !player.Ready || !room.State.Started {
+        return
+    }
+    left := *player
+    left.Connected = false
+    if !room.roundEnded {
+        room.creditPlayer(&left)
+    }
+    room.departed[userID] = &left
+}
+
+// playerResults sums up the match for every player who took part in it,
+// best placed first. Callers must hold State.Mutex.
+func (room *Room) playerResults(winners []string) []models.GameResult {
+    var players []*models.PlayerState
+    for _, player := range room.State.Players {
+        if player.Ready {
+            players = append(players, player)
+        }
+    }
+    for userID, player := range room.departed {
+        if current, stayed := room.State.Players[userID]; !stayed || !current.Ready {
+            players = append(players, player)
+        }
+    }
+
+    won := make(map[string]bool, len(winners))
+    for _, userID := range winners {
+        won[userID] = true
+    }
+
+    // Winners first, then by rounds won, score and how long they survived
+    ahead := func(a, b *models.PlayerState) bool {
+        if won[a.UserID] != won[b.UserID] {
+            return won[a.UserID]
+        }
+        if won[a.UserID] {
+            return false // Every winner shares first place
+        }
+        if a.RoundWins != b.RoundWins {
+            return a.RoundWins > b.RoundWins
+        }
+        if a.BestScore != b.BestScore {
+            return a.BestScore > b.BestScore
+        }
+        return a.TicksAlive > b.TicksAlive
+    }
+    sort.SliceStable(players, func(i, j int) bool { return ahead(players[i], players[j]) })
+
+    results := make([]models.GameResult, 0, len(players))
+    for i, player := range players {
+        placement := i + 1
+        if i > 0 && !ahead(players[i-1], player) {
+            placement = results[i-1].Placement
+        }
+        results = append(results, models.GameResult{
+            UserID:       player.UserID,
+            Mode:         room.Mode.Info().Name,
+            Score:        player.BestScore,
+            Placement:    placement,
+            TimeAlive:    (time.Duration(player.TicksAlive) * time.Second / simulation.TicksPerSecond).Milliseconds(),
+            Flaps:        player.Flaps,
+            Won:          won[player.UserID],
+            Bot:          player.Bot,
+            Disconnected: !player.Connected,
+        })
+    }
+    return results
+}
@@ -10,18 +10,19 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"github.com/mapleleafu/flaparena/flaparena-backend/models"
 	"github.com/mapleleafu/flaparena/flaparena-backend/repository"
+	"github.com/mapleleafu/flaparena/flaparena-backend/simulation"
 )
 
-func saveGameSessionToMongoDB(placeholderID string) (string, *models.GameSession) {
-    gameSessionsMutex.Lock()
-    session, exists := gameSessions[placeholderID]
-    if !exists {
-        log.Printf("Game session with placeholder ID %s not found", placeholderID)
-        gameSessionsMutex.Unlock()
+func (room *Room) saveGameSessionToMongoDB() (string, *models.GameSession) {
+    room.sessionMutex.Lock()
+    session := room.session
+    room.session = nil // Detach the session from the room
+    room.sessionMutex.Unlock()
+
+    if session == nil {
+        log.Printf("Room %s has no game session to save", room.ID)
         return "", session
     }
-    delete(gameSessions, placeholderID) // Remove the session from the map
-    gameSessionsMutex.Unlock()
     
     collection := repository.MongoDBClient.Database("flaparena").Collection("game_sessions")
     result, err := collection.InsertOne(context.Background(), session)
@@ -36,26 +37,55 @@ func saveGameSessionToMongoDB(placeholderID string) (string, *models.GameSession
     return realGameID, session
 }
 
-func createInitialGameInPostgres(gameID string) {
+func (room *Room) createInitialGameInPostgres(gameID string) {
     var userIds []string
-    for userID := range currentGameState.Players {
+    room.State.Mutex.Lock()
+    for userID := range room.State.Players {
         userIds = append(userIds, userID)
     }
+    room.State.Mutex.Unlock()
     
     db := repository.PostgreSQLDB
-    _, err := db.Exec("INSERT INTO games (id, created_at, user_ids) VALUES ($1, NOW(), $2)",
-        gameID, pq.Array(userIds))
+    _, err := db.Exec("INSERT INTO games (id, created_at, user_ids, mode, rules, bot_ids) VALUES ($1, NOW(), $2, $3, $4, $5)",
+        gameID, pq.Array(userIds), room.Mode.Info().Name, room.Rules, pq.Array(room.botIDs()))
     if err != nil {
         log.Printf("Failed to create initial game session in PostgreSQL: %v", err)
     }
 }
 
-func updateGameDataInPostgres(realGameID string) {
+// updateGameDataInPostgres finishes the game's row and records every
+// player's result along with it, all or nothing.
+func (room *Room) updateGameDataInPostgres(gameID, realGameID string, results []models.GameResult) {
     db := repository.PostgreSQLDB
-    _, err := db.Exec("UPDATE games SET finished_at = NOW(), id = $1 WHERE id = $2", 
-        realGameID, currentGameState.GameID)
+    tx, err := db.Begin()
+    if err != nil {
+        log.Printf("Failed to start transaction for game %s: %v", realGameID, err)
+        return
+    }
+    defer tx.Rollback()
+
+    _, err = tx.Exec("UPDATE games SET finished_at = NOW(), id = $1 WHERE id = $2", 
+        realGameID, gameID)
     if err != nil {
         log.Printf("Failed to update game session in PostgreSQL: %v", err)
+        return
+    }
+
+    // Without the Mongo ID there is nothing to tie the results to
+    if realGameID != "" {
+        for _, result := range results {
+            _, err = tx.Exec(`INSERT INTO game_results (game_id, user_id, mode, score, placement, time_alive_ms, flaps, won, bot, disconnected, finished_at)
+                VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW()) ON CONFLICT DO NOTHING`,
+                realGameID, result.UserID, result.Mode, result.Score, result.Placement, result.TimeAlive, result.Flaps, result.Won, result.Bot, result.Disconnected)
+            if err != nil {
+                log.Printf("Failed to save result of user %s in game %s: %v", result.UserID, realGameID, err)
+                return
+            }
+        }
+    }
+
+    if err = tx.Commit(); err != nil {
+        log.Printf("Failed to commit game %s: %v", realGameID, err)
     }
 }
 
@@ -64,7 +94,7 @@ func updateGameDataInPostgres(realGameID string) {
     
 //     var userIds []string
     
-//     for userID := range currentGameState.Players {
+//     for userID := range room.State.Players {
 //         userIds = append(userIds, userID)
 //     }
 
@@ -94,14 +124,15 @@ func updateGameDataInPostgres(realGameID string) {
 //     log.Printf("Game session saved to PostgreSQL with ID %s", gameID)
 // }
 
-func startNewGameSession() string {
+func (room *Room) startNewGameSession(course simulation.CourseParams) string {
     placeholderID  := generatePlaceholderID()
     log.Println("Generated GameID:", placeholderID )
 
-    // Initialize a new game session with this ID
-    gameSessionsMutex.Lock()
-    defer gameSessionsMutex.Unlock()
-    gameSessions[placeholderID] = &models.GameSession{}
+    // Initialize a new game session for this room
+    room.sessionMutex.Lock()
+    defer room.sessionMutex.Unlock()
+    room.session = &models.GameSession{Mode: room.Mode.Info(), Rules: room.Rules, Course: course, Bots: room.botIDs(), Actions: room.lobbyActions}
+    room.lobbyActions = nil
     return placeholderID
 }
 
@@ -4,117 +4,342 @@ import (
 	"log"
     "time"
 
+	"github.com/mapleleafu/flaparena/flaparena-backend/clock"
+	"github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
 	"github.com/mapleleafu/flaparena/flaparena-backend/models"
 )
 
-func startGame() {
+// roundBreak is the countdown between the rounds of a multi-round match.
+const roundBreak = 3
+
+func (room *Room) startGame() {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    if room.challenge != nil && room.challenge.started {
+        log.Println("Challenge attempt already played.")
+        room.broadcastMessage("challengeAttemptUsed", map[string]string{"day": room.challenge.day})
+        return
+    }
+
     readyPlayers := 0
 
-    for _, player := range currentGameState.Players {
-        if player.Ready && readyPlayers <= 20 {
+    for _, player := range room.State.Players {
+        if player.Ready {
             readyPlayers++
         }
     }
 
-    if readyPlayers >= 2 && !currentGameState.Started && checkAllPlayersReady() {
+    if !room.State.Started && room.countdown == nil && readyPlayers >= room.Rules.MinPlayers && room.Mode.CanStart(readyPlayers, len(room.State.Players)) {
         // Broadcast countdown before starting the game
-        for countdown := 5; countdown > 0; countdown-- {
-            broadcastMessage("countdown", map[string]interface{}{
-                "countdown": countdown,
-            })
-            log.Printf("Starting game in %d...", countdown)
-            time.Sleep(1 * time.Second) // Wait for a second
-        }
-        
-        GameID := startNewGameSession()  // Placeholder ID generated here
-        
-        currentGameState.Mutex.Lock()
-        currentGameState.GameID = GameID
-        currentGameState.Started = true
-        currentGameState.Mutex.Unlock()
-
-        createInitialGameInPostgres(GameID)
-
-        gameStartedAction := models.GameAction{
-            UserID:    "server",
-            Action:    "start",
-            Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
-        }
-        handleGameAction(gameStartedAction, currentGameState.GameID)
-
-        log.Println("Game started")
-        broadcastMessage("gameStart", map[string]interface{}{
-            "gameID": GameID,
-        })
-        
-    } else if readyPlayers < 2 {
-        log.Println("Not enough players to start the game.")
-    } else if currentGameState.Started {
+        room.startCountdown(room.Rules.Countdown, room.beginGame)
+    } else if room.State.Started {
         log.Println("Game already started.")
+    } else if room.countdown != nil {
+        log.Println("Countdown already running.")
     } else {
-        log.Println("Not all players are ready.")
+        log.Println("Not enough ready players to start the game.")
+    }
+}
+
+// beginGame starts the match once its countdown has run out, unless someone
+// unreadied or left in the meantime.
+func (room *Room) beginGame(finished *countdown) {
+    room.State.Mutex.Lock()
+    if room.countdown != finished {
+        room.State.Mutex.Unlock()
+        log.Println("Countdown cancelled.")
+        return
+    }
+    room.countdown = nil
+    room.State.Started = true
+    room.State.Mutex.Unlock()
+
+    course := room.newCourseParams()
+    GameID := room.startNewGameSession(course)  // Placeholder ID generated here
+
+    room.State.Mutex.Lock()
+    room.State.GameID = GameID
+    room.State.Course = course
+    room.State.Round = 0
+    if room.Rules.MaxDuration > 0 {
+        maxDuration := time.Duration(room.Rules.MaxDuration) * time.Second
+        room.deadline = room.clock.Now().Add(maxDuration)
+        room.scheduler.After(maxDuration, func() { room.timeoutGame(GameID) })
+    }
+    room.State.Mutex.Unlock()
+
+    room.createInitialGameInPostgres(GameID)
+    room.startChallengeAttempt()
+
+    gameStartedAction := models.GameAction{
+        UserID:    "server",
+        Action:    "start",
+        Timestamp: room.clock.Now().UnixMilli(),
     }
+    room.handleGameAction(gameStartedAction)
+
+    log.Println("Game started")
+    room.broadcastMessage("gameStart", map[string]interface{}{
+        "gameID": GameID,
+        "course": course,
+        "tickRate": serverConfig.TickRate,
+        "mode": room.Mode.Info(),
+        "rules": room.Rules,
+    })
+    room.startRound()
+}
+
+// countdown is a running countdown to a game or to the next round.
+type countdown struct {
+    timer clock.Timer
+}
+
+// startCountdown broadcasts one message a second from the room's scheduler
+// and then hands the finished countdown to done, which has to check that it
+// is still room.countdown before acting on it. Callers must hold State.Mutex.
+func (room *Room) startCountdown(seconds int, done func(*countdown)) {
+    started := &countdown{}
+    room.countdown = started
+    started.timer = room.scheduler.After(0, func() { room.countdownTick(started, seconds, done) })
 }
 
+func (room *Room) countdownTick(running *countdown, remaining int, done func(*countdown)) {
+    room.State.Mutex.Lock()
+    if room.countdown != running {
+        room.State.Mutex.Unlock()
+        return
+    }
+    if remaining <= 0 {
+        room.State.Mutex.Unlock()
+        done(running)
+        return
+    }
+    running.timer = room.scheduler.After(time.Second, func() { room.countdownTick(running, remaining-1, done) })
+    room.State.Mutex.Unlock()
+
+    room.broadcastMessage("countdown", map[string]interface{}{
+        "countdown": remaining,
+    })
+    log.Printf("Starting in %d...", remaining)
+}
 
-func endGame() {
-    if checkAllPlayersDead() {
-        gameID := currentGameState.GameID
-        log.Println("Game ended")
+// cancelCountdown stops the countdown to a game if userID is one of the
+// ready players it is waiting on. It reports whether it did.
+func (room *Room) cancelCountdown(userID string) bool {
+    room.State.Mutex.Lock()
+    player, exists := room.State.Players[userID]
+    cancelled := exists && player.Ready && !room.State.Started && room.stopCountdown()
+    room.State.Mutex.Unlock()
 
-        gameEndedAction := models.GameAction{
-            UserID:    "server",
-            Action:    "end",
-            Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+    if cancelled {
+        room.broadcastMessage("countdownCancelled", map[string]string{"userID": userID})
+    }
+    return cancelled
+}
+
+// stopCountdown aborts the running countdown, if there is one. Callers must
+// hold State.Mutex.
+func (room *Room) stopCountdown() bool {
+    if room.countdown == nil {
+        return false
+    }
+    room.countdown.timer.Stop()
+    room.countdown = nil
+    return true
+}
+
+// startRound puts every ready player back in the air on the game's course.
+func (room *Room) startRound() {
+    room.State.Mutex.Lock()
+    room.State.Round++
+    round := room.State.Round
+    for _, player := range room.State.Players {
+        if player.Ready {
+            player.Alive = true
+            player.Score = 0
+            player.DiedAt = 0
         }
-        handleGameAction(gameEndedAction, currentGameState.GameID)
+    }
+    room.State.StartedAt = room.clock.Now()
+    for _, player := range room.State.Players {
+        // Idle time counts from the start of the round
+        if player.Ready {
+            player.LastInput = room.State.StartedAt
+        }
+    }
+    room.State.World = room.newGameWorld(room.State.Course)
+    room.roundEnded = false
+    room.State.Mutex.Unlock()
 
-        broadcastMessage("gameEnd", map[string]interface{}{
-            "gameID": gameID,
-        })
+    room.handleGameAction(models.GameAction{
+        UserID:    "server",
+        Action:    "roundStart",
+        Timestamp: room.clock.Now().UnixMilli(),
+    })
+    room.broadcastMessage("roundStart", map[string]interface{}{"round": round})
+    room.startGameLoop()
+
+    // Timed modes end the round on the dot rather than on the next tick
+    if timeLimit := room.Mode.Info().TimeLimit; timeLimit > 0 {
+        room.scheduler.After(time.Duration(timeLimit)*time.Second, room.checkRoundOver)
+    }
 
-        realGameID, _ := saveGameSessionToMongoDB(currentGameState.GameID)
+    // Seats freed since the last round go to the waiting list
+    if room.promoteWaiting() {
+        room.broadcastGameState()
+    }
+}
+
+// checkRoundOver asks the room's mode whether the round is finished and ends it if so.
+func (room *Room) checkRoundOver() {
+    room.State.Mutex.Lock()
+    if !room.State.Started || room.roundEnded {
+        room.State.Mutex.Unlock()
+        return
+    }
+    over := room.Mode.RoundOver(room.modePlayers(), room.clock.Now().Sub(room.State.StartedAt))
+    room.roundEnded = over
+    room.State.Mutex.Unlock()
 
-        updateGameDataInPostgres(realGameID)
-        resetGameState()
+    if over {
+        room.endRound()
+    }
+}
+
+// endRound credits the round's winners and either plays another round or
+// finishes the match.
+func (room *Room) endRound() {
+    room.stopGameLoop()
+
+    room.State.Mutex.Lock()
+    round := room.State.Round
+    winners := room.settleRound()
+    matchOver := room.Mode.MatchOver(round, room.modePlayers())
+    room.State.Mutex.Unlock()
+
+    room.handleGameAction(models.GameAction{
+        UserID:    "server",
+        Action:    "roundEnd",
+        Timestamp: room.clock.Now().UnixMilli(),
+    })
+    room.broadcastMessage("roundEnd", map[string]interface{}{
+        "round": round,
+        "winners": winners,
+    })
+    log.Printf("Round %d ended in room %s", round, room.ID)
+
+    if matchOver {
+        room.endGame()
     } else {
-        log.Println("Not all players dead yet.")
+        room.State.Mutex.Lock()
+        room.startCountdown(roundBreak, room.nextRound)
+        room.State.Mutex.Unlock()
     }
 }
 
-func resetGameState() {
-    currentGameState.GameID = "" // Reset placeholder ID
-    currentGameState.Players = make(map[string]*models.PlayerState) // Reset players
-    currentGameState.Started = false // Reset game state
+// nextRound starts the following round of a match once the break between
+// rounds has counted down.
+func (room *Room) nextRound(finished *countdown) {
+    // Everyone may have left during the break
+    room.State.Mutex.Lock()
+    active := room.countdown == finished && room.State.Started && !room.closed
+    if active {
+        room.countdown = nil
+    }
+    expired := !room.deadline.IsZero() && !room.clock.Now().Before(room.deadline)
+    room.State.Mutex.Unlock()
+
+    if active && expired {
+        room.endTimedOutGame()
+    } else if active {
+        room.startRound()
+    }
 }
 
-func checkAllPlayersReady() bool {
-    currentGameState.Mutex.Lock()
-    defer currentGameState.Mutex.Unlock()
+func (room *Room) endGame() {
+    log.Println("Game ended")
+    room.stopGameLoop()
 
-    for _, player := range currentGameState.Players {
-        if !player.Ready {
-            return false
-        }
+    room.State.Mutex.Lock()
+    gameID := room.State.GameID
+    winners := room.Mode.MatchWinners(room.modePlayers())
+    results := room.playerResults(winners)
+    room.State.Mutex.Unlock()
+
+    gameEndedAction := models.GameAction{
+        UserID:    "server",
+        Action:    "end",
+        Timestamp: room.clock.Now().UnixMilli(),
     }
-    return true
+    room.handleGameAction(gameEndedAction)
+
+    room.broadcastMessage("gameEnd", map[string]interface{}{
+        "gameID": gameID,
+        "winners": winners,
+    })
+
+    realGameID, session := room.saveGameSessionToMongoDB()
+
+    room.updateGameDataInPostgres(gameID, realGameID, results)
+    room.updateRatings(realGameID, results)
+    room.awardAchievements(realGameID, session, results)
+    room.reportTournamentResult(realGameID, winners)
+    room.reportChallengeResult(realGameID, results)
+    room.resetGameState()
+    if room.promoteWaiting() {
+        room.broadcastGameState()
+    }
+}
+
+func (room *Room) resetGameState() {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    room.State.GameID = "" // Reset placeholder ID
+    // Keep everyone in the room, but send them back to the lobby
+    for _, player := range room.State.Players {
+        player.Ready = false
+        player.Alive = false
+        player.Score = 0
+        player.DiedAt = 0
+        player.RoundWins = 0
+        player.BestScore = 0
+        player.Flaps = 0
+        player.TicksAlive = 0
+        player.LastInput = room.clock.Now() // The lobby timeout starts over
+    }
+    room.departed = make(map[string]*models.PlayerState)
+    room.deadline = time.Time{}
+    room.State.Started = false // Reset game state
+    room.State.Round = 0
+    room.State.World = nil // Drop the finished simulation
 }
 
-func checkAllPlayersDead() bool {
-    for _, player := range currentGameState.Players {
-        if player.Alive {
-            return false
+// modePlayers converts the players for the room's mode. Callers must hold State.Mutex.
+func (room *Room) modePlayers() []gamemode.Player {
+    players := make([]gamemode.Player, 0, len(room.State.Players))
+    for _, player := range room.State.Players {
+        if !player.Ready {
+            continue
         }
+        players = append(players, gamemode.Player{
+            UserID:    player.UserID,
+            Alive:     player.Alive,
+            Score:     player.Score,
+            DiedAt:    player.DiedAt,
+            RoundWins: player.RoundWins,
+        })
     }
-    return true
+    return players
 }
 
-func playerScored(userID string) {
-    currentGameState.Mutex.Lock()
-    defer currentGameState.Mutex.Unlock()
+func (room *Room) playerScored(userID string) {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
 
-    if player, exists := currentGameState.Players[userID]; exists {
-        player.Score++
+    if player, exists := room.State.Players[userID]; exists {
+        player.Score += room.Mode.PointsPerPipe()
     }
     
     log.Printf("Player %s scored", userID)
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/common"
+    "github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+    "github.com/mapleleafu/flaparena/flaparena-backend/repository"
+    "github.com/mapleleafu/flaparena/flaparena-backend/responses"
+    "github.com/mapleleafu/flaparena/flaparena-backend/utils"
+)
+
+// FetchLeaderboard ranks players by ?metric= (score, wins or rating) over
+// ?period= (all, season, week or day), optionally for a single ?mode=.
+// Practice and daily challenge games are left out unless ?mode= asks for
+// one of them.
+// Seasons are calendar quarters named like 2026-Q3 and default to the
+// current one. Pages are fetched with ?limit= and the returned next_cursor.
+// Ratings are only kept all-time and across modes.
+func FetchLeaderboard(w http.ResponseWriter, r *http.Request) {
+    authInfo, ok := r.Context().Value(common.AuthInfoKey).(*models.CustomClaims)
+    if !ok {
+        utils.HandleError(w, responses.InternalServerError{Msg: "Error processing request."})
+        return
+    }
+
+    query := r.URL.Query()
+    board := models.Leaderboard{
+        Metric: query.Get("metric"),
+        Period: query.Get("period"),
+        Mode:   query.Get("mode"),
+    }
+    if board.Metric == "" {
+        board.Metric = models.MetricScore
+    }
+    if board.Period == "" {
+        board.Period = models.PeriodAll
+    }
+
+    limit, err := pageLimit(r)
+    if err != nil {
+        utils.HandleError(w, responses.BadRequestError{Msg: err.Error()})
+        return
+    }
+
+    if board.Mode != "" {
+        if _, err := gamemode.New(board.Mode, 0, 0); err != nil {
+            utils.HandleError(w, responses.BadRequestError{Msg: err.Error()})
+            return
+        }
+    }
+
+    var conditions []string
+    var args []interface{}
+    addCondition := func(condition string, value interface{}) {
+        args = append(args, value)
+        conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+    }
+
+    switch board.Period {
+    case models.PeriodAll:
+    case models.PeriodSeason:
+        board.Season = query.Get("season")
+        if board.Season == "" {
+            board.Season = seasonOf(time.Now())
+        }
+        start, end, err := seasonBounds(board.Season)
+        if err != nil {
+            utils.HandleError(w, responses.BadRequestError{Msg: err.Error()})
+            return
+        }
+        addCondition("finished_at >= $%d", start)
+        addCondition("finished_at < $%d", end)
+    case models.PeriodWeek:
+        conditions = append(conditions, "finished_at >= date_trunc('week', NOW())")
+    case models.PeriodDay:
+        conditions = append(conditions, "finished_at >= date_trunc('day', NOW())")
+    default:
+        utils.HandleError(w, responses.BadRequestError{Msg: "period must be all, season, week or day."})
+        return
+    }
+    if board.Mode != "" {
+        addCondition("mode = $%d", board.Mode)
+    } else {
+        // Solo runs are replayable, so they stay off the shared boards
+        args = append(args, gamemode.Practice, gamemode.DailyChallenge)
+        conditions = append(conditions, fmt.Sprintf("mode NOT IN ($%d, $%d)", len(args)-1, len(args)))
+    }
+
+    var totals string
+    switch board.Metric {
+    case models.MetricScore, models.MetricWins:
+        value := "MAX(score)"
+        if board.Metric == models.MetricWins {
+            value = "COUNT(*) FILTER (WHERE won)"
+        }
+        where := "NOT bot"
+        if len(conditions) > 0 {
+            where += " AND " + strings.Join(conditions, " AND ")
+        }
+        totals = "SELECT user_id, " + value + "::float8 AS value, COUNT(*) AS games FROM game_results WHERE " + where + " GROUP BY user_id"
+    case models.MetricRating:
+        if board.Period != models.PeriodAll || board.Mode != "" {
+            utils.HandleError(w, responses.BadRequestError{Msg: "Ratings are only ranked all-time across modes."})
+            return
+        }
+        totals = "SELECT user_id, rating AS value, games FROM ratings"
+    default:
+        utils.HandleError(w, responses.BadRequestError{Msg: "metric must be score, wins or rating."})
+        return
+    }
+    board.Entries, board.NextCursor, board.Me, err = loadRanking(totals, args, query.Get("cursor"), limit, authInfo.ID)
+    if err == errInvalidCursor {
+        utils.HandleError(w, responses.BadRequestError{Msg: "Invalid cursor."})
+        return
+    } else if err != nil {
+        log.Printf("Error fetching leaderboard: %v", err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to fetch leaderboard."})
+        return
+    }
+
+    utils.HandleSuccess(w, models.SuccessResponse(board))
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// loadRanking ranks the users of totals, a query for user_id, value and
+// games with the given args, highest value first. It returns the page after
+// cursor, the cursor of the page after that and userID's own entry.
+func loadRanking(totals string, args []interface{}, cursor string, limit int, userID string) ([]models.LeaderboardEntry, string, *models.LeaderboardEntry, error) {
+    ranked := `WITH totals AS (` + totals + `),
+        ranked AS (SELECT user_id, value, games, RANK() OVER (ORDER BY value DESC) AS rank FROM totals)
+        SELECT r.rank, r.user_id, COALESCE(u.username, ''), r.value, r.games
+        FROM ranked r LEFT JOIN users u ON u.id::text = r.user_id`
+
+    // Entries are ordered by value, then user ID, which the cursor points into
+    pageQuery := ranked
+    pageArgs := append([]interface{}(nil), args...)
+    if cursor != "" {
+        value, afterUserID, err := decodeCursor(cursor)
+        if err != nil {
+            return nil, "", nil, errInvalidCursor
+        }
+        pageArgs = append(pageArgs, value, afterUserID)
+        pageQuery += fmt.Sprintf(" WHERE r.value < $%d OR (r.value = $%d AND r.user_id > $%d)",
+            len(pageArgs)-1, len(pageArgs)-1, len(pageArgs))
+    }
+    pageArgs = append(pageArgs, limit+1)
+    pageQuery += fmt.Sprintf(" ORDER BY r.value DESC, r.user_id ASC LIMIT $%d", len(pageArgs))
+
+    db := repository.PostgreSQLDB
+    rows, err := db.Query(pageQuery, pageArgs...)
+    if err != nil {
+        return nil, "", nil, err
+    }
+    defer rows.Close()
+
+    entries := make([]models.LeaderboardEntry, 0, limit)
+    for rows.Next() {
+        var entry models.LeaderboardEntry
+        if err := rows.Scan(&entry.Rank, &entry.UserID, &entry.Username, &entry.Value, &entry.Games); err != nil {
+            return nil, "", nil, err
+        }
+        entries = append(entries, entry)
+    }
+    if err = rows.Err(); err != nil {
+        return nil, "", nil, err
+    }
+
+    var nextCursor string
+    if len(entries) > limit {
+        entries = entries[:limit]
+        last := entries[limit-1]
+        nextCursor = encodeCursor(last.Value, last.UserID)
+    }
+
+    var me models.LeaderboardEntry
+    err = db.QueryRow(ranked+fmt.Sprintf(" WHERE r.user_id = $%d", len(args)+1), append(args, userID)...).
+        Scan(&me.Rank, &me.UserID, &me.Username, &me.Value, &me.Games)
+    if err == sql.ErrNoRows {
+        return entries, nextCursor, nil, nil
+    } else if err != nil {
+        return nil, "", nil, err
+    }
+    return entries, nextCursor, &me, nil
+}
+
+// seasonOf names the season t falls in.
+func seasonOf(t time.Time) string {
+    t = t.UTC()
+    return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+}
+
+// seasonBounds returns when a season such as 2026-Q3 starts and ends.
+func seasonBounds(season string) (time.Time, time.Time, error) {
+    var year, quarter int
+    if _, err := fmt.Sscanf(season, "%d-Q%d", &year, &quarter); err != nil || quarter < 1 || quarter > 4 {
+        return time.Time{}, time.Time{}, fmt.Errorf("season must look like 2026-Q3")
+    }
+    start := time.Date(year, time.Month((quarter-1)*3+1), 1, 0, 0, 0, 0, time.UTC)
+    return start, start.AddDate(0, 3, 0), nil
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+    "encoding/json"
+    "log"
+    "math"
+    "sort"
+    "sync"
+    "time"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/clock"
+    "github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+    "github.com/mapleleafu/flaparena/flaparena-backend/rating"
+)
+
+// defaultRating is the rating players are matched with until they have one.
+const defaultRating = rating.DefaultRating
+
+// Matchmaking search window, in rating points. A player is first matched
+// only with others close to their rating; the window widens the longer
+// they wait so nobody waits forever.
+const (
+    baseSearchWindow    = 100.0
+    searchWindowGrowth  = 10.0 // per second spent in the queue
+    maxSearchWindow     = 600.0
+    minMatchSize        = 2
+    partialMatchTimeout = 30 * time.Second // start with fewer players after this long
+)
+
+type queueEntry struct {
+    connection *Connection
+    rating     float64
+    joinedAt   time.Time
+}
+
+// searchWindow returns how far from their rating a player accepts opponents at now.
+func (e *queueEntry) searchWindow(now time.Time) float64 {
+    waited := now.Sub(e.joinedAt).Seconds()
+    return math.Min(baseSearchWindow+searchWindowGrowth*waited, maxSearchWindow)
+}
+
+// Matchmaker groups queued players of similar rating into new rooms. The
+// queue lives in memory only.
+type Matchmaker struct {
+    queue     []*queueEntry
+    matchSize int
+    clock     clock.Clock
+    mutex     sync.Mutex
+}
+
+var matchmaker *Matchmaker
+
+func newMatchmaker(matchSize int, c clock.Clock) *Matchmaker {
+    return &Matchmaker{matchSize: matchSize, clock: c}
+}
+
+// Enqueue adds a connection to the queue. It reports false if it is already queued.
+func (m *Matchmaker) Enqueue(c *Connection, now time.Time) bool {
+    m.mutex.Lock()
+    defer m.mutex.Unlock()
+
+    for _, entry := range m.queue {
+        if entry.connection == c {
+            return false
+        }
+    }
+    m.queue = append(m.queue, &queueEntry{connection: c, rating: c.currentRating(), joinedAt: now})
+    return true
+}
+
+// Remove takes a connection out of the queue. It reports whether it was queued.
+func (m *Matchmaker) Remove(c *Connection) bool {
+    m.mutex.Lock()
+    defer m.mutex.Unlock()
+
+    for i, entry := range m.queue {
+        if entry.connection == c {
+            m.queue = append(m.queue[:i], m.queue[i+1:]...)
+            return true
+        }
+    }
+    return false
+}
+
+// Len returns the number of queued players.
+func (m *Matchmaker) Len() int {
+    m.mutex.Lock()
+    defer m.mutex.Unlock()
+
+    return len(m.queue)
+}
+
+// FindMatches takes every group that can be matched at now out of the queue.
+// Players who have waited longest are matched first, each with the queued
+// players closest to their rating that both sides' search windows accept.
+func (m *Matchmaker) FindMatches(now time.Time) [][]*Connection {
+    m.mutex.Lock()
+    defer m.mutex.Unlock()
+
+    // The queue is kept in join order, so the first entry waited longest
+    matched := make(map[*queueEntry]bool)
+    var matches [][]*Connection
+
+    for _, anchor := range m.queue {
+        if matched[anchor] {
+            continue
+        }
+
+        window := anchor.searchWindow(now)
+        candidates := []*queueEntry{anchor}
+        for _, entry := range m.queue {
+            if entry == anchor || matched[entry] {
+                continue
+            }
+            diff := math.Abs(entry.rating - anchor.rating)
+            if diff <= window && diff <= entry.searchWindow(now) {
+                candidates = append(candidates, entry)
+            }
+        }
+
+        // Prefer the closest ratings when there are more candidates than seats
+        sort.SliceStable(candidates[1:], func(i, j int) bool {
+            return math.Abs(candidates[1+i].rating-anchor.rating) < math.Abs(candidates[1+j].rating-anchor.rating)
+        })
+        if len(candidates) > m.matchSize {
+            candidates = candidates[:m.matchSize]
+        }
+
+        full := len(candidates) == m.matchSize
+        waitedLongEnough := len(candidates) >= minMatchSize && now.Sub(anchor.joinedAt) >= partialMatchTimeout
+        if !full && !waitedLongEnough {
+            continue
+        }
+
+        group := make([]*Connection, 0, len(candidates))
+        for _, entry := range candidates {
+            matched[entry] = true
+            group = append(group, entry.connection)
+        }
+        matches = append(matches, group)
+    }
+
+    remaining := m.queue[:0]
+    for _, entry := range m.queue {
+        if !matched[entry] {
+            remaining = append(remaining, entry)
+        }
+    }
+    m.queue = remaining
+
+    return matches
+}
+
+// run checks the queue for matches every interval on the matchmaker's clock.
+func (m *Matchmaker) run(interval time.Duration) {
+    m.clock.AfterFunc(interval, func() {
+        for _, group := range m.FindMatches(m.clock.Now()) {
+            startMatch(group)
+        }
+        m.run(interval)
+    })
+}
+
+// startMatch moves a matched group into a fresh room and tells each player where they are.
+func startMatch(group []*Connection) {
+    mode, _ := gamemode.New(gamemode.LastBirdStanding, 0, 0)
+    room := rooms.Create("", mode, models.DefaultRoomRules())
+
+    players := make([]string, 0, len(group))
+    for _, c := range group {
+        // Players who disconnected since being matched are simply left out
+        if room.join(c, false) {
+            players = append(players, c.userIDString())
+        }
+    }
+    log.Printf("Matched %d players into room %s", len(players), room.ID)
+
+    for _, c := range group {
+        c.sendMessage("matchFound", map[string]interface{}{
+            "roomID":  room.ID,
+            "players": players,
+        })
+    }
+}
+
+// handleQueueAction moves a player from their room into the matchmaking queue.
+func (room *Room) handleQueueAction(c *Connection) {
+    if started, _ := room.gameStatus(c.userIDString()); started {
+        c.sendMessage("gameAlreadyStarted", map[string]string{"userID": c.userIDString()})
+        return
+    }
+
+    c.leaveRoom(false)
+    matchmaker.Enqueue(c, matchmaker.clock.Now())
+    c.sendMessage("queued", map[string]interface{}{
+        "userID": c.userIDString(),
+        "queueSize": matchmaker.Len(),
+    })
+}
+
+// handleQueuedMessage handles messages from connections waiting for a match.
+// They can only leave the queue, which puts them back in the lobby.
+func handleQueuedMessage(c *Connection, rawMessage []byte) {
+    var gameActionMsg models.GameActionMessage
+    if err := json.Unmarshal(rawMessage, &gameActionMsg); err != nil {
+        log.Printf("Error unmarshalling game action message: %v", err)
+        return
+    }
+
+    switch gameActionMsg.Action {
+        case "leaveQueue":
+            if matchmaker.Remove(c) {
+                lobby, _ := rooms.Get(lobbyRoomID)
+                lobby.join(c, false)
+            }
+        default:
+            c.sendMessage("inQueue", map[string]string{"userID": c.userIDString()})
+    }
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestFindMatches(t *testing.T) {
+    type queued struct {
+        rating float64
+        joined time.Duration // after the first player joined
+    }
+
+    tests := []struct {
+        name      string
+        matchSize int
+        queue     []queued
+        now       time.Duration
+        want      [][]int // indexes into queue
+    }{
+        {
+            name:      "close ratings match at once",
+            matchSize: 2,
+            queue:     []queued{{1500, 0}, {1550, 0}},
+            now:       0,
+            want:      [][]int{{0, 1}},
+        },
+        {
+            name:      "distant ratings wait",
+            matchSize: 2,
+            queue:     []queued{{1500, 0}, {1700, 0}},
+            now:       9 * time.Second,
+        },
+        {
+            name:      "window widens while waiting",
+            matchSize: 2,
+            queue:     []queued{{1500, 0}, {1700, 0}},
+            now:       10 * time.Second,
+            want:      [][]int{{0, 1}},
+        },
+        {
+            name:      "both windows must accept",
+            matchSize: 2,
+            queue:     []queued{{1500, 0}, {1700, 10 * time.Second}},
+            now:       10 * time.Second,
+        },
+        {
+            name:      "window stops widening",
+            matchSize: 2,
+            queue:     []queued{{1500, 0}, {2150, 0}},
+            now:       10 * time.Minute,
+        },
+        {
+            name:      "closest ratings take the seats",
+            matchSize: 2,
+            queue:     []queued{{1500, 0}, {1590, time.Second}, {1520, 2 * time.Second}},
+            now:       2 * time.Second,
+            want:      [][]int{{0, 2}},
+        },
+        {
+            name:      "longest waiting player is matched first",
+            matchSize: 2,
+            queue:     []queued{{1600, 0}, {1540, time.Second}, {1500, 2 * time.Second}},
+            now:       2 * time.Second,
+            want:      [][]int{{0, 1}},
+        },
+        {
+            name:      "several groups at once",
+            matchSize: 2,
+            queue:     []queued{{1500, 0}, {2000, 0}, {1510, 0}, {2010, 0}},
+            now:       0,
+            want:      [][]int{{0, 2}, {1, 3}},
+        },
+        {
+            name:      "partial group waits for the timeout",
+            matchSize: 4,
+            queue:     []queued{{1500, 0}, {1510, 0}, {1520, 0}},
+            now:       partialMatchTimeout - time.Millisecond,
+        },
+        {
+            name:      "partial group starts after the timeout",
+            matchSize: 4,
+            queue:     []queued{{1500, 0}, {1510, 0}, {1520, 0}},
+            now:       partialMatchTimeout,
+            want:      [][]int{{0, 1, 2}},
+        },
+        {
+            name:      "partial timeout counts from the anchor's join",
+            matchSize: 4,
+            queue:     []queued{{1500, 10 * time.Second}, {1510, 10 * time.Second}},
+            now:       partialMatchTimeout,
+        },
+        {
+            name:      "nobody plays alone",
+            matchSize: 4,
+            queue:     []queued{{1500, 0}},
+            now:       10 * time.Minute,
+        },
+    }
+
+    start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            m := newMatchmaker(test.matchSize, nil)
+            connections := make([]*Connection, len(test.queue))
+            for i, entry := range test.queue {
+                connections[i] = &Connection{userID: uint64(i + 1), rating: entry.rating}
+                if !m.Enqueue(connections[i], start.Add(entry.joined)) {
+                    t.Fatalf("player %d could not be queued", i)
+                }
+            }
+
+            var got [][]int
+            for _, group := range m.FindMatches(start.Add(test.now)) {
+                var indexes []int
+                for _, c := range group {
+                    indexes = append(indexes, int(c.userID-1))
+                }
+                got = append(got, indexes)
+            }
+            if !reflect.DeepEqual(got, test.want) {
+                t.Fatalf("matches %v, want %v", got, test.want)
+            }
+
+            matched := 0
+            for _, group := range test.want {
+                matched += len(group)
+            }
+            if m.Len() != len(test.queue)-matched {
+                t.Fatalf("%d players left in the queue, want %d", m.Len(), len(test.queue)-matched)
+            }
+        })
+    }
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+    "encoding/base64"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+)
+
+const (
+    defaultPageLimit = 20
+    maxPageLimit     = 100
+)
+
+// pageLimit reads the ?limit= of a paginated request.
+func pageLimit(r *http.Request) (int, error) {
+    value := r.URL.Query().Get("limit")
+    if value == "" {
+        return defaultPageLimit, nil
+    }
+    limit, err := strconv.Atoi(value)
+    if err != nil || limit < 1 || limit > maxPageLimit {
+        return 0, fmt.Errorf("limit must be between 1 and %d.", maxPageLimit)
+    }
+    return limit, nil
+}
+
+// Cursors point just past the last entry of a page, which is identified by
+// the value the list is sorted on and an ID to break ties with.
+func encodeCursor(value float64, id string) string {
+    return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatFloat(value, 'g', -1, 64) + ":" + id))
+}
+
+func decodeCursor(cursor string) (float64, string, error) {
+    decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+    if err != nil {
+        return 0, "", err
+    }
+    parts := strings.SplitN(string(decoded), ":", 2)
+    if len(parts) != 2 {
+        return 0, "", fmt.Errorf("malformed cursor")
+    }
+    value, err := strconv.ParseFloat(parts[0], 64)
+    return value, parts[1], err
+}
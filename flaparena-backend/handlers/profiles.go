@@ -0,0 +1,135 @@
+package handlers
+
+import (
+    "database/sql"
+    "log"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/gorilla/mux"
+    "github.com/mapleleafu/flaparena/flaparena-backend/common"
+    "github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+    "github.com/mapleleafu/flaparena/flaparena-backend/rating"
+    "github.com/mapleleafu/flaparena/flaparena-backend/repository"
+    "github.com/mapleleafu/flaparena/flaparena-backend/responses"
+    "github.com/mapleleafu/flaparena/flaparena-backend/utils"
+)
+
+// recentFormGames is how many of the latest games make up a user's form.
+const recentFormGames = 10
+
+// userRecord picks the results of user $1 that make up their record. Solo
+// games have no winner, so they would only ever count as losses.
+const userRecord = "user_id = $1 AND mode NOT IN ('" + gamemode.Practice + "', '" + gamemode.DailyChallenge + "')"
+
+// GetUserProfile returns a user's profile and statistics.
+func GetUserProfile(w http.ResponseWriter, r *http.Request) {
+    userID, err := strconv.ParseUint(mux.Vars(r)["userID"], 10, 64)
+    if err != nil {
+        utils.HandleError(w, responses.BadRequestError{Msg: "Invalid user ID."})
+        return
+    }
+    writeProfile(w, userID)
+}
+
+// GetMyProfile returns the caller's own profile and statistics.
+func GetMyProfile(w http.ResponseWriter, r *http.Request) {
+    authInfo, ok := r.Context().Value(common.AuthInfoKey).(*models.CustomClaims)
+    if !ok {
+        utils.HandleError(w, responses.InternalServerError{Msg: "Error processing request."})
+        return
+    }
+
+    userID, err := strconv.ParseUint(authInfo.ID, 10, 64)
+    if err != nil {
+        utils.HandleError(w, responses.InternalServerError{Msg: "Error processing request."})
+        return
+    }
+    writeProfile(w, userID)
+}
+
+func writeProfile(w http.ResponseWriter, userID uint64) {
+    profile, err := loadProfile(userID)
+    if err == sql.ErrNoRows {
+        utils.HandleError(w, responses.NotFoundError{Msg: "User not found."})
+        return
+    } else if err != nil {
+        log.Printf("Error fetching profile of user %d: %v", userID, err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to fetch profile."})
+        return
+    }
+
+    utils.HandleSuccess(w, models.SuccessResponse(profile))
+}
+
+// loadProfile aggregates the user's game results in the database, so its
+// cost doesn't grow with what is sent back however many games they played.
+func loadProfile(userID uint64) (models.Profile, error) {
+    db := repository.PostgreSQLDB
+    var profile models.Profile
+    profile.Rating = rating.Default()
+    err := db.QueryRow(`SELECT u.id, u.username, COALESCE(r.rating, $2), COALESCE(r.deviation, $3), COALESCE(r.volatility, $4), COALESCE(r.games, 0)
+        FROM users u LEFT JOIN ratings r ON r.user_id = u.id::text WHERE u.id = $1`,
+        userID, rating.DefaultRating, rating.DefaultDeviation, rating.DefaultVolatility).
+        Scan(&profile.ID, &profile.Username, &profile.Rating.Rating, &profile.Rating.Deviation, &profile.Rating.Volatility, &profile.RatedGames)
+    if err != nil {
+        return profile, err
+    }
+
+    userIDStr := strconv.FormatUint(userID, 10)
+    profile.Achievements, err = loadAchievements(userIDStr)
+    if err != nil {
+        return profile, err
+    }
+
+    stats := &profile.Stats
+    err = db.QueryRow(`SELECT COUNT(*), COUNT(*) FILTER (WHERE won), COALESCE(MAX(score), 0), COALESCE(AVG(score), 0),
+            COALESCE(SUM(flaps), 0), COALESCE(AVG(time_alive_ms), 0)
+        FROM game_results WHERE `+userRecord, userIDStr).
+        Scan(&stats.GamesPlayed, &stats.Wins, &stats.BestScore, &stats.AverageScore, &stats.TotalFlaps, &stats.AverageTimeAlive)
+    if err != nil {
+        return profile, err
+    }
+    if stats.GamesPlayed == 0 {
+        return profile, nil
+    }
+
+    // Every run of wins gets its own group: the gap between a game's place
+    // among all games and among wins only stays the same along the run
+    err = db.QueryRow(`WITH runs AS (
+            SELECT won, finished_at,
+                ROW_NUMBER() OVER (ORDER BY finished_at) - ROW_NUMBER() OVER (PARTITION BY won ORDER BY finished_at) AS run
+            FROM game_results WHERE `+userRecord+`
+        ), streaks AS (
+            SELECT COUNT(*) AS length, MAX(finished_at) AS ended_at FROM runs WHERE won GROUP BY run
+        )
+        SELECT COALESCE(MAX(length), 0),
+            COALESCE(MAX(length) FILTER (WHERE ended_at = (SELECT MAX(finished_at) FROM game_results WHERE `+userRecord+`)), 0)
+        FROM streaks`, userIDStr).Scan(&stats.LongestStreak, &stats.CurrentStreak)
+    if err != nil {
+        return profile, err
+    }
+
+    rows, err := db.Query("SELECT won FROM game_results WHERE "+userRecord+" ORDER BY finished_at DESC LIMIT $2", userIDStr, recentFormGames)
+    if err != nil {
+        return profile, err
+    }
+    defer rows.Close()
+
+    var form strings.Builder
+    for rows.Next() {
+        var won bool
+        if err := rows.Scan(&won); err != nil {
+            return profile, err
+        }
+        if won {
+            form.WriteString("W")
+        } else {
+            form.WriteString("L")
+        }
+    }
+    stats.RecentForm = form.String()
+    return profile, rows.Err()
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+    "database/sql"
+    "log"
+    "sort"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+    "github.com/mapleleafu/flaparena/flaparena-backend/rating"
+    "github.com/mapleleafu/flaparena/flaparena-backend/repository"
+)
+
+// ranked reports whether the room's games count towards ratings. Games in
+// private rooms, tournament matches included, are played among friends,
+// and practice never counts.
+func (room *Room) ranked() bool {
+    return !room.Private && room.Mode.Info().Name != gamemode.Practice
+}
+
+// loadRating returns the user's current rating, or the starting rating if
+// they have none yet.
+func loadRating(userID string) float64 {
+    var current float64
+    err := repository.PostgreSQLDB.QueryRow("SELECT rating FROM ratings WHERE user_id = $1", userID).Scan(&current)
+    if err != nil {
+        if err != sql.ErrNoRows {
+            log.Printf("Error fetching rating of user %s: %v", userID, err)
+        }
+        return rating.DefaultRating
+    }
+    return current
+}
+
+// updateRatings rates a finished ranked game among the people who played
+// it, bots left out, and records everyone's new rating with its history.
+func (room *Room) updateRatings(gameID string, results []models.GameResult) {
+    if gameID == "" || !room.ranked() {
+        return
+    }
+
+    var rated []models.GameResult
+    for _, result := range results {
+        if !result.Bot {
+            rated = append(rated, result)
+        }
+    }
+    if len(rated) < 2 {
+        return
+    }
+    // Lock the rows in the same order as every other game does
+    sort.Slice(rated, func(i, j int) bool { return rated[i].UserID < rated[j].UserID })
+
+    db := repository.PostgreSQLDB
+    tx, err := db.Begin()
+    if err != nil {
+        log.Printf("Error starting transaction for ratings of game %s: %v", gameID, err)
+        return
+    }
+    defer tx.Rollback()
+
+    standings := make([]rating.Standing, len(rated))
+    for i, result := range rated {
+        current := rating.Default()
+        err := tx.QueryRow("SELECT rating, deviation, volatility FROM ratings WHERE user_id = $1 FOR UPDATE", result.UserID).
+            Scan(&current.Rating, &current.Deviation, &current.Volatility)
+        if err != nil && err != sql.ErrNoRows {
+            log.Printf("Error fetching rating of user %s: %v", result.UserID, err)
+            return
+        }
+        standings[i] = rating.Standing{Rating: current, Placement: result.Placement}
+    }
+
+    updated := rating.Update(standings)
+    for i, result := range rated {
+        _, err = tx.Exec(`INSERT INTO ratings (user_id, rating, deviation, volatility, games, updated_at) VALUES ($1, $2, $3, $4, 1, NOW())
+            ON CONFLICT (user_id) DO UPDATE SET rating = $2, deviation = $3, volatility = $4, games = ratings.games + 1, updated_at = NOW()`,
+            result.UserID, updated[i].Rating, updated[i].Deviation, updated[i].Volatility)
+        if err != nil {
+            log.Printf("Error updating rating of user %s: %v", result.UserID, err)
+            return
+        }
+        _, err = tx.Exec(`INSERT INTO rating_history (game_id, user_id, rating_before, rating_after, deviation, volatility)
+            VALUES ($1, $2, $3, $4, $5, $6)`,
+            gameID, result.UserID, standings[i].Rating.Rating, updated[i].Rating, updated[i].Deviation, updated[i].Volatility)
+        if err != nil {
+            log.Printf("Error recording rating history of user %s: %v", result.UserID, err)
+            return
+        }
+    }
+
+    if err = tx.Commit(); err != nil {
+        log.Printf("Error committing ratings of game %s: %v", gameID, err)
+        return
+    }
+
+    // Everyone still here sees their new rating in the lobby and is
+    // matched by it from now on
+    room.State.Mutex.Lock()
+    for i, result := range rated {
+        if player, exists := room.State.Players[result.UserID]; exists {
+            player.Rating = updated[i].Rating
+        }
+        if c, exists := room.members[result.UserID]; exists {
+            c.setRating(updated[i].Rating)
+        }
+    }
+    room.State.Mutex.Unlock()
+}
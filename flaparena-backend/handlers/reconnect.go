@@ -0,0 +1,126 @@
+package handlers
+
+import (
+    "crypto/rand"
+    "encoding/base64"
+    "log"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+)
+
+// holdSeat marks a dropped player as disconnected instead of removing them,
+// and releases the seat if they don't come back within the grace period.
+// It reports false if seats aren't held at all.
+func (room *Room) holdSeat(userID string) bool {
+    grace := serverConfig.ReconnectGrace
+    if grace <= 0 {
+        return false
+    }
+
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    player, exists := room.State.Players[userID]
+    if !exists {
+        return false
+    }
+    player.Connected = false
+    if !room.State.Started {
+        // Nobody should have to wait on a player who isn't there
+        player.Ready = false
+        player.Alive = false
+    }
+    room.graceTimers[userID] = room.scheduler.After(grace, func() { room.expireSeat(userID) })
+    return true
+}
+
+// issueResumeToken gives c's user a token to take their seat back with
+// after a dropped connection. Bots never reconnect, and without a grace
+// period no seat is held to take back. Callers must hold State.Mutex.
+func (room *Room) issueResumeToken(c *Connection) {
+    if c.bot || serverConfig.ReconnectGrace <= 0 {
+        return
+    }
+    room.resumeTokens[c.userIDString()] = generateResumeToken()
+}
+
+// reattachPlayer gives a disconnected player their seat back. Callers must
+// hold State.Mutex.
+func (room *Room) reattachPlayer(player *models.PlayerState) {
+    player.Connected = true
+    if timer, exists := room.graceTimers[player.UserID]; exists {
+        timer.Stop()
+        delete(room.graceTimers, player.UserID)
+    }
+    log.Printf("User %s resumed their seat in room %s", player.UserID, room.ID)
+}
+
+// expireSeat removes a player whose grace period ran out without a reconnect.
+func (room *Room) expireSeat(userID string) {
+    room.State.Mutex.Lock()
+    player, exists := room.State.Players[userID]
+    if !exists || player.Connected {
+        room.State.Mutex.Unlock()
+        return
+    }
+    killed := room.State.Started && room.markDead(userID)
+    room.keepResult(userID)
+    delete(room.State.Players, userID)
+    delete(room.resumeTokens, userID)
+    delete(room.graceTimers, userID)
+    room.State.Mutex.Unlock()
+
+    log.Printf("User %s did not reconnect to room %s in time", userID, room.ID)
+    if killed {
+        room.announceDeath(userID, room.clock.Now().UnixMilli())
+    }
+    room.promoteWaiting()
+    room.broadcastGameState()
+    rooms.RemoveIfEmpty(room)
+}
+
+// hasResumeToken reports whether token lets userID back into this room.
+func (room *Room) hasResumeToken(userID, token string) bool {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    expected, exists := room.resumeTokens[userID]
+    return exists && expected == token
+}
+
+// sendWelcome tells a connection which room it is in, the token to resume
+// its seat with and, if a game is running, everything needed to render it.
+func (room *Room) sendWelcome(c *Connection) {
+    userIDStr := c.userIDString()
+
+    room.State.Mutex.Lock()
+    welcome := map[string]interface{}{
+        "roomID":      room.ID,
+        "role":        c.role,
+        "resumeToken": room.resumeTokens[userIDStr],
+    }
+    if position := room.waitingPosition(userIDStr); position > 0 {
+        welcome["position"] = position
+    }
+    if room.State.Started && room.State.World != nil {
+        welcome["game"] = map[string]interface{}{
+            "gameID":   room.State.GameID,
+            "course":   room.State.World.Course.Params,
+            "tickRate": serverConfig.TickRate,
+            "tick":     room.State.World.Tick,
+            "mode":     room.Mode.Info(),
+            "rules":    room.Rules,
+        }
+    }
+    room.State.Mutex.Unlock()
+
+    c.sendMessage("welcome", welcome)
+}
+
+func generateResumeToken() string {
+    tokenBytes := make([]byte, 32)
+    if _, err := rand.Read(tokenBytes); err != nil {
+        log.Printf("Error generating resume token: %v", err)
+    }
+    return base64.URLEncoding.EncodeToString(tokenBytes)
+}
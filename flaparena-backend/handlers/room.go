@@ -0,0 +1,370 @@
+package handlers
+
+import (
+    "log"
+    "sort"
+    "sync"
+    "time"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/clock"
+    "github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+)
+
+// Room is a single match: its players, the connections watching it and the
+// game session being recorded. Rooms never share state with each other.
+type Room struct {
+    ID    string
+    State *models.GameState
+    Mode  gamemode.Mode
+    Rules models.RoomRules
+    hub   *Hub
+
+    // Runs the room's countdowns, game loop and timeouts on its own goroutine.
+    clock     clock.Clock
+    scheduler *scheduler
+
+    // Private rooms can only be joined with their invite code and are
+    // never listed or used for matchmaking.
+    Private    bool
+    OwnerID    string
+    InviteCode string
+
+    session      *models.GameSession
+    sessionMutex sync.Mutex
+
+    // Server actions taken in the lobby, recorded at the start of the next
+    // game session. Guarded by sessionMutex.
+    lobbyActions []models.GameAction
+
+    // The running game loop and countdown, if any. Guarded by State.Mutex.
+    loop      *gameLoop
+    countdown *countdown
+
+    // When the running game hits the room's maximum duration. Guarded by
+    // State.Mutex.
+    deadline time.Time
+
+    // Set once the current round has been decided. Guarded by State.Mutex.
+    roundEnded bool
+
+    // Set once the room has been removed from the manager.
+    closed bool
+
+    // Resume tokens and reconnect timers of players, keyed by user ID.
+    // Guarded by State.Mutex.
+    resumeTokens map[string]string
+    graceTimers  map[string]clock.Timer
+
+    // Connections waiting for a player slot, first in line first.
+    // Guarded by State.Mutex.
+    waiting []*Connection
+
+    // The tournament match played in the room until it is decided, and the
+    // only users who may play in it. Guarded by State.Mutex.
+    tournamentMatch string
+    allowedPlayers  map[string]bool
+
+    // The connection of everyone in the room, keyed by user ID. Guarded by
+    // State.Mutex.
+    members map[string]*Connection
+
+    // Bots playing in the room, keyed by user ID. Guarded by State.Mutex.
+    bots map[string]*bot
+
+    // Players who left the running game, kept for its results. Guarded by
+    // State.Mutex.
+    departed map[string]*models.PlayerState
+
+    // The daily challenge attempt the room was opened for, if any. Guarded
+    // by State.Mutex.
+    challenge *challengeAttempt
+}
+
+func newRoom(id string, mode gamemode.Mode, rules models.RoomRules) *Room {
+    room := &Room{
+        ID: id,
+        Mode: mode,
+        Rules: rules,
+        State: &models.GameState{
+            Players: make(map[string]*models.PlayerState),
+            Spectators: make(map[string]*models.SpectatorState),
+            Started: false,
+            GameID:  "",
+        },
+        hub: newHub(),
+        clock: roomClock,
+        scheduler: newScheduler(roomClock),
+        resumeTokens: make(map[string]string),
+        graceTimers:  make(map[string]clock.Timer),
+        members:      make(map[string]*Connection),
+        bots:         make(map[string]*bot),
+        departed:     make(map[string]*models.PlayerState),
+    }
+    go room.hub.run()
+    go room.scheduler.run()
+    if rules.LobbyTimeout > 0 {
+        room.scheduler.After(lobbyCheckInterval, room.watchLobby)
+    }
+    return room
+}
+
+// info summarizes the room for the rooms API.
+func (room *Room) info() models.RoomInfo {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    return models.RoomInfo{
+        ID:      room.ID,
+        Players: len(room.State.Players),
+        Spectators: room.spectatorNames(),
+        Waiting: len(room.waiting),
+        Started: room.State.Started,
+        Private: room.Private,
+        Mode:    room.Mode.Info(),
+        Rules:   room.Rules,
+        OwnerID: room.OwnerID,
+    }
+}
+
+// ownedBy reports whether userID owns the room. Rooms opened by the server
+// have no owner.
+func (room *Room) ownedBy(userID string) bool {
+    return room.OwnerID != "" && room.OwnerID == userID
+}
+
+// addMember puts a connection's user into the room as a player, or as a
+// spectator if they asked to watch or the game is already running. Would-be
+// players who find the room full go on its waiting list. A player who
+// dropped and is still inside the grace period gets their seat back. It
+// fails if the room has already been closed.
+func (room *Room) addMember(c *Connection, spectate bool) (connectionRole, bool) {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    if room.closed {
+        return "", false
+    }
+
+    userIDStr := c.userIDString()
+    room.members[userIDStr] = c
+    if room.allowedPlayers != nil && !room.allowedPlayers[userIDStr] {
+        spectate = true
+    }
+    if player, exists := room.State.Players[userIDStr]; exists && !player.Connected {
+        room.reattachPlayer(player)
+        player.LastInput = room.clock.Now()
+        return rolePlayer, true
+    }
+
+    if !spectate && len(room.State.Players) >= room.Rules.MaxPlayers {
+        room.waiting = append(room.waiting, c)
+        return roleWaiting, true
+    }
+
+    if spectate || room.State.Started {
+        room.State.Spectators[userIDStr] = &models.SpectatorState{
+            UserID:   userIDStr,
+            Username: c.username,
+        }
+        return roleSpectator, true
+    }
+
+    room.State.Players[userIDStr] = room.newPlayerState(c)
+    room.issueResumeToken(c)
+    return rolePlayer, true
+}
+
+// newPlayerState seats c's user as a connected player who hasn't readied
+// up yet, however they got their seat.
+func (room *Room) newPlayerState(c *Connection) *models.PlayerState {
+    return &models.PlayerState{
+        UserID:    c.userIDString(),
+        Username:  c.username,
+        Connected: true,
+        Bot:       c.bot,
+        Rating:    c.currentRating(),
+        LastInput: room.clock.Now(),
+    }
+}
+
+// removeMember takes a user out of the room, whether they were playing or watching.
+func (room *Room) removeMember(userID string) {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    room.keepResult(userID)
+    delete(room.State.Players, userID)
+    delete(room.State.Spectators, userID)
+    delete(room.resumeTokens, userID)
+    delete(room.bots, userID)
+    delete(room.members, userID)
+    room.removeWaiting(userID)
+}
+
+// join moves a connection that isn't in any room into this one and starts
+// delivering the room's broadcasts to it.
+func (room *Room) join(c *Connection, spectate bool) bool {
+    c.roomMutex.Lock()
+    defer c.roomMutex.Unlock()
+
+    if c.left || c.room != nil {
+        return false
+    }
+
+    role, joined := room.addMember(c, spectate)
+    if !joined {
+        log.Printf("User %d tried to join closed room %s", c.userID, room.ID)
+        return false
+    }
+    c.room = room
+    c.role = role
+
+    // Register the connection to the room's hub for broadcasting and message handling
+    room.hub.Register(c)
+    log.Printf("User %d joined room %s as %s", c.userID, room.ID, role)
+
+    room.sendWelcome(c)
+
+    // Broadcast updated game state to all connections in the room
+    room.broadcastGameState()
+    return true
+}
+
+// removeConnection is the other half of join. When keepSeat is set a player
+// is only marked as disconnected and can resume within the grace period;
+// otherwise leaving a running game kills the player's bird. Callers go
+// through Connection.leaveRoom.
+func (room *Room) removeConnection(c *Connection, keepSeat bool) {
+    room.hub.Unregister(c)
+
+    userIDStr := c.userIDString()
+    cancelled := room.cancelCountdown(userIDStr)
+    if keepSeat && c.role == rolePlayer && room.holdSeat(userIDStr) {
+        log.Printf("User %d dropped from room %s, holding their seat", c.userID, room.ID)
+        room.broadcastGameState()
+        return
+    }
+
+    room.releaseSeat(userIDStr)
+    if cancelled {
+        // Everyone left behind may still be ready to go
+        room.startGame()
+    }
+}
+
+// releaseSeat takes a player or spectator out of the room for good.
+func (room *Room) releaseSeat(userIDStr string) {
+    room.State.Mutex.Lock()
+    killed := room.State.Started && room.markDead(userIDStr)
+    room.State.Mutex.Unlock()
+    if killed {
+        room.announceDeath(userIDStr, room.clock.Now().UnixMilli())
+    }
+
+    // Remove the user from the game state
+    room.removeMember(userIDStr)
+    log.Printf("User %s left room %s", userIDStr, room.ID)
+    room.promoteWaiting()
+    room.broadcastGameState()
+    rooms.RemoveIfEmpty(room)
+}
+
+// switchRole turns a spectator into a player or the other way around. A
+// spectator asking to play in a full room joins the waiting list instead, and
+// leaves it again by asking to spectate. Roles can only change while no game
+// is running.
+func (room *Room) switchRole(c *Connection, role connectionRole) bool {
+    c.roomMutex.Lock()
+    defer c.roomMutex.Unlock()
+
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    if c.room != room || c.role == role || room.State.Started {
+        return false
+    }
+    if c.role == roleWaiting && role == rolePlayer {
+        return false
+    }
+    if role == rolePlayer && room.allowedPlayers != nil && !room.allowedPlayers[c.userIDString()] {
+        return false
+    }
+
+    userIDStr := c.userIDString()
+    delete(room.State.Players, userIDStr)
+    delete(room.State.Spectators, userIDStr)
+    room.removeWaiting(userIDStr)
+
+    if role == roleSpectator {
+        room.State.Spectators[userIDStr] = &models.SpectatorState{UserID: userIDStr, Username: c.username}
+    } else if len(room.State.Players) >= room.Rules.MaxPlayers {
+        room.waiting = append(room.waiting, c)
+        role = roleWaiting
+    } else {
+        room.State.Players[userIDStr] = room.newPlayerState(c)
+    }
+    c.role = role
+    return true
+}
+
+// holdTournamentMatch reserves the room for a tournament match between the
+// given players. The room stays open until the match is decided. An empty
+// matchID releases the room again.
+func (room *Room) holdTournamentMatch(matchID string, players ...string) {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    room.tournamentMatch = matchID
+    room.allowedPlayers = nil
+    if matchID == "" {
+        return
+    }
+    room.allowedPlayers = make(map[string]bool)
+    for _, userID := range players {
+        room.allowedPlayers[userID] = true
+    }
+}
+
+// tournamentMatchID returns the undecided tournament match played in the
+// room, if there is one.
+func (room *Room) tournamentMatchID() string {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    return room.tournamentMatch
+}
+
+// markClosedIfEmpty closes the room to new players if nobody is left in it.
+// It reports whether the room was closed by this call.
+func (room *Room) markClosedIfEmpty() bool {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    if room.closed || room.tournamentMatch != "" || len(room.State.Players) > 0 || len(room.State.Spectators) > 0 || len(room.waiting) > 0 {
+        return false
+    }
+    room.closed = true
+    return true
+}
+
+// close shuts down the room's hub and any game still running in it.
+func (room *Room) close() {
+    room.State.Mutex.Lock()
+    room.stopCountdown()
+    room.State.Mutex.Unlock()
+
+    room.stopGameLoop()
+    room.scheduler.Stop()
+    close(room.hub.stop)
+}
+
+// spectatorNames lists who is watching the room. Callers must hold State.Mutex.
+func (room *Room) spectatorNames() []string {
+    names := make([]string, 0, len(room.State.Spectators))
+    for _, spectator := range room.State.Spectators {
+        names = append(names, spectator.Username)
+    }
+    sort.Strings(names)
+    return names
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+    "crypto/rand"
+    "log"
+    "math/big"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+)
+
+// lobbyRoomID is the room players join when they don't ask for a specific one.
+// It always exists.
+const lobbyRoomID = "lobby"
+
+// emptyRoomTimeout is how long a newly created room waits for its first player.
+const emptyRoomTimeout = 5 * time.Minute
+
+// Invite codes avoid letters and digits that are easy to mix up when read aloud.
+const (
+    inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+    inviteCodeLength   = 6
+)
+
+// RoomManager keeps track of every room running on this server.
+type RoomManager struct {
+    rooms       map[string]*Room
+    inviteCodes map[string]*Room
+    mutex       sync.Mutex
+}
+
+var rooms *RoomManager
+
+func newRoomManager() *RoomManager {
+    manager := &RoomManager{
+        rooms:       make(map[string]*Room),
+        inviteCodes: make(map[string]*Room),
+    }
+    lobbyMode, _ := gamemode.New(gamemode.LastBirdStanding, 0, 0)
+    manager.rooms[lobbyRoomID] = newRoom(lobbyRoomID, lobbyMode, models.DefaultRoomRules())
+    return manager
+}
+
+// Create opens a new empty public room. Rooms opened by the server, such
+// as matchmade ones, have no owner and pass an empty ownerID.
+func (m *RoomManager) Create(ownerID string, mode gamemode.Mode, rules models.RoomRules) *Room {
+    room := newRoom(uuid.New().String(), mode, rules)
+    room.OwnerID = ownerID
+
+    m.mutex.Lock()
+    m.rooms[room.ID] = room
+    m.mutex.Unlock()
+
+    m.expireIfUnused(room)
+    log.Printf("Room %s created", room.ID)
+    return room
+}
+
+// CreatePrivate opens a new invite-only room owned by ownerID.
+func (m *RoomManager) CreatePrivate(ownerID string, mode gamemode.Mode, rules models.RoomRules) (*Room, error) {
+    room := newRoom(uuid.New().String(), mode, rules)
+    room.Private = true
+    room.OwnerID = ownerID
+
+    m.mutex.Lock()
+    for room.InviteCode == "" || m.inviteCodes[room.InviteCode] != nil {
+        code, err := generateInviteCode()
+        if err != nil {
+            m.mutex.Unlock()
+            return nil, err
+        }
+        room.InviteCode = code
+    }
+    m.rooms[room.ID] = room
+    m.inviteCodes[room.InviteCode] = room
+    m.mutex.Unlock()
+
+    m.expireIfUnused(room)
+    log.Printf("Private room %s created by user %s", room.ID, ownerID)
+    return room, nil
+}
+
+// expireIfUnused drops rooms nobody ever joined.
+func (m *RoomManager) expireIfUnused(room *Room) {
+    room.scheduler.After(emptyRoomTimeout, func() { m.RemoveIfEmpty(room) })
+}
+
+func (m *RoomManager) Get(roomID string) (*Room, bool) {
+    m.mutex.Lock()
+    defer m.mutex.Unlock()
+
+    room, exists := m.rooms[roomID]
+    return room, exists
+}
+
+// GetByInviteCode finds a private room by its invite code, ignoring case.
+func (m *RoomManager) GetByInviteCode(code string) (*Room, bool) {
+    m.mutex.Lock()
+    defer m.mutex.Unlock()
+
+    room, exists := m.inviteCodes[strings.ToUpper(strings.TrimSpace(code))]
+    return room, exists
+}
+
+// FindByResumeToken finds the room in which userID holds a seat resumable with token.
+func (m *RoomManager) FindByResumeToken(userID, token string) (*Room, bool) {
+    m.mutex.Lock()
+    defer m.mutex.Unlock()
+
+    for _, room := range m.rooms {
+        if room.hasResumeToken(userID, token) {
+            return room, true
+        }
+    }
+    return nil, false
+}
+
+// RemoveIfEmpty closes a room once its last player has left. The lobby is kept.
+func (m *RoomManager) RemoveIfEmpty(room *Room) {
+    room.dismissBotsIfAlone()
+    if room.ID == lobbyRoomID || !room.markClosedIfEmpty() {
+        return
+    }
+
+    m.mutex.Lock()
+    delete(m.rooms, room.ID)
+    if room.InviteCode != "" {
+        delete(m.inviteCodes, room.InviteCode)
+    }
+    m.mutex.Unlock()
+
+    room.close()
+    room.releaseChallengeAttempt()
+    log.Printf("Room %s closed", room.ID)
+}
+
+// List returns a summary of every public room, ordered by ID.
+func (m *RoomManager) List() []models.RoomInfo {
+    m.mutex.Lock()
+    roomList := make([]*Room, 0, len(m.rooms))
+    for _, room := range m.rooms {
+        if !room.Private {
+            roomList = append(roomList, room)
+        }
+    }
+    m.mutex.Unlock()
+
+    infos := make([]models.RoomInfo, 0, len(roomList))
+    for _, room := range roomList {
+        infos = append(infos, room.info())
+    }
+    sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+    return infos
+}
+
+func generateInviteCode() (string, error) {
+    code := make([]byte, inviteCodeLength)
+    max := big.NewInt(int64(len(inviteCodeAlphabet)))
+    for i := range code {
+        n, err := rand.Int(rand.Reader, max)
+        if err != nil {
+            return "", err
+        }
+        code[i] = inviteCodeAlphabet[n.Int64()]
+    }
+    return string(code), nil
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+    "encoding/json"
+    "io"
+    "log"
+    "net/http"
+    "time"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/common"
+    "github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+    "github.com/mapleleafu/flaparena/flaparena-backend/responses"
+    "github.com/mapleleafu/flaparena/flaparena-backend/utils"
+)
+
+func ListRooms(w http.ResponseWriter, r *http.Request) {
+    utils.HandleSuccess(w, models.SuccessResponse(rooms.List()))
+}
+
+// CreateRoom opens a new room owned by the caller that players can join
+// through /ws/{roomID}/{token}.
+func CreateRoom(w http.ResponseWriter, r *http.Request) {
+    authInfo, ok := r.Context().Value(common.AuthInfoKey).(*models.CustomClaims)
+    if !ok {
+        utils.HandleError(w, responses.InternalServerError{Msg: "Error processing request."})
+        return
+    }
+
+    mode, rules, err := decodeRoomSettings(r)
+    if err != nil {
+        utils.HandleError(w, err)
+        return
+    }
+
+    room := rooms.Create(authInfo.ID, mode, rules)
+    utils.HandleSuccess(w, models.SuccessResponse(room.info()))
+}
+
+// CreatePrivateRoom opens an invite-only room owned by the caller. Others
+// join it through /ws/{token}?invite={inviteCode}.
+func CreatePrivateRoom(w http.ResponseWriter, r *http.Request) {
+    authInfo, ok := r.Context().Value(common.AuthInfoKey).(*models.CustomClaims)
+    if !ok {
+        utils.HandleError(w, responses.InternalServerError{Msg: "Error processing request."})
+        return
+    }
+
+    mode, rules, err := decodeRoomSettings(r)
+    if err != nil {
+        utils.HandleError(w, err)
+        return
+    }
+
+    room, err := rooms.CreatePrivate(authInfo.ID, mode, rules)
+    if err != nil {
+        log.Printf("Error creating private room: %v", err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to create room."})
+        return
+    }
+
+    info := room.info()
+    info.InviteCode = room.InviteCode
+    utils.HandleSuccess(w, models.SuccessResponse(info))
+}
+
+// decodeRoomSettings reads the game mode and rules from an optional
+// CreateRoomRequest body. Without a body the room plays last bird standing
+// with the default rules.
+func decodeRoomSettings(r *http.Request) (gamemode.Mode, models.RoomRules, error) {
+    request := models.CreateRoomRequest{Rules: models.DefaultRoomRules()}
+    if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+        return nil, request.Rules, responses.BadRequestError{Msg: "Invalid request."}
+    }
+
+    mode, err := gamemode.New(request.Mode, request.Rounds, time.Duration(request.TimeLimit)*time.Second)
+    if err != nil {
+        return nil, request.Rules, responses.BadRequestError{Msg: err.Error()}
+    }
+    if mode.Info().Name == gamemode.Practice {
+        // Practice is flown alone whatever the rules asked for
+        request.Rules.MinPlayers = 1
+        request.Rules.MaxPlayers = 1
+    }
+
+    if err := request.Rules.Validate(); err != nil {
+        return nil, request.Rules, responses.BadRequestError{Msg: err.Error()}
+    }
+    if err := mode.CheckPlayers(request.Rules.MinPlayers, request.Rules.MaxPlayers); err != nil {
+        return nil, request.Rules, responses.BadRequestError{Msg: err.Error()}
+    }
+    return mode, request.Rules, nil
+}
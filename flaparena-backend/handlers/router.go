@@ -1,11 +1,23 @@
 package handlers
 
 import (
+    "time"
+
     "github.com/gorilla/mux"
+    "github.com/mapleleafu/flaparena/flaparena-backend/config"
     "github.com/mapleleafu/flaparena/flaparena-backend/middleware"
 )
 
-func NewRouter() *mux.Router {
+// serverConfig holds the settings the game handlers run with.
+var serverConfig *config.Config
+
+func NewRouter(cfg *config.Config) *mux.Router {
+    serverConfig = cfg
+    rooms = newRoomManager()
+    matchmaker = newMatchmaker(cfg.MatchSize, roomClock)
+    matchmaker.run(time.Second)
+    reopenTournamentMatches()
+
     r := mux.NewRouter()
     
     // Public routes
@@ -13,12 +25,28 @@ func NewRouter() *mux.Router {
     r.HandleFunc("/api/login", Login).Methods("POST")
     r.HandleFunc("/api/refresh/token", RefreshToken).Methods("POST")
     r.HandleFunc("/ws/{token}", WsHandler)
+    r.HandleFunc("/ws/{roomID}/{token}", WsHandler)
 
     // Secured routes
     secured := r.PathPrefix("/api").Subrouter()
     secured.Use(middleware.JWTValidationMiddleware)
     secured.HandleFunc("/games", FetchUserGames).Methods("GET")
     secured.HandleFunc("/game/{gameID}", FetchGameActions).Methods("GET")
+    secured.HandleFunc("/rooms", ListRooms).Methods("GET")
+    secured.HandleFunc("/rooms", CreateRoom).Methods("POST")
+    secured.HandleFunc("/rooms/private", CreatePrivateRoom).Methods("POST")
+    secured.HandleFunc("/tournaments", ListTournaments).Methods("GET")
+    secured.HandleFunc("/tournaments", CreateTournament).Methods("POST")
+    secured.HandleFunc("/tournaments/{tournamentID}", GetTournament).Methods("GET")
+    secured.HandleFunc("/tournaments/{tournamentID}/register", RegisterForTournament).Methods("POST")
+    secured.HandleFunc("/tournaments/{tournamentID}/start", StartTournament).Methods("POST")
+    secured.HandleFunc("/leaderboard", FetchLeaderboard).Methods("GET")
+    secured.HandleFunc("/users/{userID}", GetUserProfile).Methods("GET")
+    secured.HandleFunc("/me", GetMyProfile).Methods("GET")
+    secured.HandleFunc("/users/{userID}/versus/{otherID}", GetVersus).Methods("GET")
+    secured.HandleFunc("/challenge", GetDailyChallenge).Methods("GET")
+    secured.HandleFunc("/challenge/attempts", StartChallengeAttempt).Methods("POST")
+    secured.HandleFunc("/challenge/leaderboard", FetchChallengeLeaderboard).Methods("GET")
 	secured.HandleFunc("/logout", Logout).Methods("POST")
     return r
 }
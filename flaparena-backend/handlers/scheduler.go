@@ -0,0 +1,61 @@
+package handlers
+
+import (
+    "time"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/clock"
+)
+
+// roomClock is the clock every new room runs on. Swap it for a
+// clock.Fake to drive rooms by hand.
+var roomClock clock.Clock = clock.Real{}
+
+// scheduler runs a room's timed work, such as countdowns, the game loop and
+// timeouts, one task at a time on the room's own goroutine, so none of it
+// ever blocks a player's connection.
+type scheduler struct {
+    clock clock.Clock
+    tasks chan func()
+    stop  chan struct{}
+}
+
+func newScheduler(c clock.Clock) *scheduler {
+    return &scheduler{
+        clock: c,
+        tasks: make(chan func()),
+        stop:  make(chan struct{}),
+    }
+}
+
+func (s *scheduler) run() {
+    for {
+        select {
+        case task := <-s.tasks:
+            task()
+        case <-s.stop:
+            return
+        }
+    }
+}
+
+// After runs task on the scheduler's goroutine once d has passed. Tasks
+// that come due after the scheduler has stopped are dropped.
+//
+// The timer firing waits for its task to finish, so clock.Fake.Advance only
+// returns once every task that fell due has run, along with anything those
+// tasks scheduled in the meantime.
+func (s *scheduler) After(d time.Duration, task func()) clock.Timer {
+    return s.clock.AfterFunc(d, func() {
+        done := make(chan struct{})
+        select {
+        case s.tasks <- func() { defer close(done); task() }:
+            <-done
+        case <-s.stop:
+        }
+    })
+}
+
+// Stop ends the scheduler's goroutine. It must only be called once.
+func (s *scheduler) Stop() {
+    close(s.stop)
+}
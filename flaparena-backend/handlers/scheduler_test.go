@@ -0,0 +1,288 @@
+package handlers
+
+import (
+    "database/sql"
+    "database/sql/driver"
+    "encoding/json"
+    "io"
+    "testing"
+    "time"
+
+    "go.mongodb.org/mongo-driver/mongo"
+    "go.mongodb.org/mongo-driver/mongo/options"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/clock"
+    "github.com/mapleleafu/flaparena/flaparena-backend/config"
+    "github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+    "github.com/mapleleafu/flaparena/flaparena-backend/repository"
+)
+
+func init() {
+    sql.Register("stub", stubDriver{})
+}
+
+// stubDriver stands in for Postgres: every statement succeeds and every
+// query comes back empty.
+type stubDriver struct{}
+
+func (stubDriver) Open(string) (driver.Conn, error) { return stubConn{}, nil }
+
+type stubConn struct{}
+
+func (stubConn) Prepare(string) (driver.Stmt, error) { return stubStmt{}, nil }
+func (stubConn) Close() error                        { return nil }
+func (stubConn) Begin() (driver.Tx, error)           { return stubTx{}, nil }
+
+type stubStmt struct{}
+
+func (stubStmt) Close() error                               { return nil }
+func (stubStmt) NumInput() int                              { return -1 }
+func (stubStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(0), nil }
+func (stubStmt) Query([]driver.Value) (driver.Rows, error)  { return stubRows{}, nil }
+
+type stubTx struct{}
+
+func (stubTx) Commit() error   { return nil }
+func (stubTx) Rollback() error { return nil }
+
+type stubRows struct{}
+
+func (stubRows) Columns() []string         { return nil }
+func (stubRows) Close() error              { return nil }
+func (stubRows) Next([]driver.Value) error { return io.EOF }
+
+// newTestRoom opens a room running on a fake clock and seats the given
+// number of players in it. Postgres is stubbed out and Mongo is never
+// connected, so finished games are logged as unsaved.
+func newTestRoom(t *testing.T, mode gamemode.Mode, rules models.RoomRules, players int) (*Room, *clock.Fake, []*Connection) {
+    t.Helper()
+
+    fake := clock.NewFake(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+    previousClock := roomClock
+    roomClock = fake
+    t.Cleanup(func() { roomClock = previousClock })
+
+    db, err := sql.Open("stub", "")
+    if err != nil {
+        t.Fatal(err)
+    }
+    mongoClient, err := mongo.NewClient(options.Client().ApplyURI("mongodb://localhost:27017"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    repository.PostgreSQLDB = db
+    repository.MongoDBClient = mongoClient
+    serverConfig = &config.Config{TickRate: 20, ReconnectGrace: 15 * time.Second}
+    rooms = newRoomManager()
+
+    room := rooms.Create("", mode, rules)
+    connections := make([]*Connection, players)
+    for i := range connections {
+        c := &Connection{send: make(chan []byte, 256), userID: uint64(i + 1), username: "player"}
+        go func() {
+            for range c.send {
+            }
+        }()
+        if !room.join(c, false) {
+            t.Fatalf("player %d could not join", i+1)
+        }
+        connections[i] = c
+    }
+    return room, fake, connections
+}
+
+func sendAction(room *Room, c *Connection, action string) {
+    room.processMessage(c, []byte(`{"action":"`+action+`"}`))
+}
+
+// roomStatus reads what the tests check about a room.
+func roomStatus(room *Room) (started bool, round int, countingDown bool) {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    return room.State.Started, room.State.Round, room.countdown != nil
+}
+
+// watchGameEnd seats a spectator in the room and returns the winners of
+// the next game it sees end.
+func watchGameEnd(t *testing.T, room *Room) <-chan []string {
+    t.Helper()
+
+    c := &Connection{send: make(chan []byte, 256), userID: 1000, username: "spectator"}
+    if !room.join(c, true) {
+        t.Fatal("spectator could not join")
+    }
+    winners := make(chan []string, 1)
+    go func() {
+        for raw := range c.send {
+            var message struct {
+                Type string `json:"type"`
+                Data struct {
+                    Winners []string `json:"winners"`
+                } `json:"data"`
+            }
+            if json.Unmarshal(raw, &message) == nil && message.Type == "gameEnd" {
+                winners <- message.Data.Winners
+            }
+        }
+    }()
+    return winners
+}
+
+func mustMode(t *testing.T, name string, rounds int) gamemode.Mode {
+    t.Helper()
+
+    mode, err := gamemode.New(name, rounds, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return mode
+}
+
+func TestCountdownStartsGame(t *testing.T) {
+    rules := models.DefaultRoomRules()
+    room, fake, players := newTestRoom(t, mustMode(t, gamemode.LastBirdStanding, 0), rules, 2)
+
+    sendAction(room, players[0], "ready")
+    fake.Advance(time.Duration(rules.Countdown) * time.Second)
+    if _, _, countingDown := roomStatus(room); countingDown {
+        t.Fatal("countdown started before everyone was ready")
+    }
+
+    sendAction(room, players[1], "ready")
+    fake.Advance(time.Duration(rules.Countdown)*time.Second - time.Millisecond)
+    if started, _, countingDown := roomStatus(room); started || !countingDown {
+        t.Fatalf("before the countdown ran out: started %v, counting down %v", started, countingDown)
+    }
+
+    fake.Advance(time.Millisecond)
+    if started, round, countingDown := roomStatus(room); !started || round != 1 || countingDown {
+        t.Fatalf("after the countdown: started %v, round %d, counting down %v", started, round, countingDown)
+    }
+}
+
+func TestUnreadyCancelsCountdown(t *testing.T) {
+    rules := models.DefaultRoomRules()
+    room, fake, players := newTestRoom(t, mustMode(t, gamemode.LastBirdStanding, 0), rules, 2)
+
+    sendAction(room, players[0], "ready")
+    sendAction(room, players[1], "ready")
+    fake.Advance(2 * time.Second)
+    sendAction(room, players[1], "unready")
+
+    fake.Advance(time.Duration(rules.Countdown) * time.Second)
+    if started, _, countingDown := roomStatus(room); started || countingDown {
+        t.Fatalf("after unready: started %v, counting down %v", started, countingDown)
+    }
+}
+
+func TestRoundBreakStartsNextRound(t *testing.T) {
+    rules := models.DefaultRoomRules()
+    rules.Countdown = 0
+    room, fake, players := newTestRoom(t, mustMode(t, gamemode.BestOf, 3), rules, 2)
+
+    sendAction(room, players[0], "ready")
+    sendAction(room, players[1], "ready")
+    fake.Advance(0)
+    started := fake.Now()
+
+    // Nobody flaps, so both birds hit the ground within the first second
+    fake.Advance(time.Second)
+    if _, round, countingDown := roomStatus(room); round != 1 || !countingDown {
+        t.Fatalf("after the first round: round %d, counting down %v", round, countingDown)
+    }
+
+    // The break counts down from when the round ended, somewhat after it began
+    fake.Advance(started.Add(roundBreak * time.Second).Sub(fake.Now()))
+    if _, round, _ := roomStatus(room); round != 1 {
+        t.Fatalf("round %d started before the break was over", round)
+    }
+
+    fake.Advance(time.Second)
+    if running, round, countingDown := roomStatus(room); !running || round != 2 || countingDown {
+        t.Fatalf("after the break: started %v, round %d, counting down %v", running, round, countingDown)
+    }
+}
+
+func TestMaxDurationEndsGame(t *testing.T) {
+    rules := models.DefaultRoomRules()
+    rules.Countdown = 0
+    rules.MaxDuration = 10
+    room, fake, players := newTestRoom(t, mustMode(t, gamemode.BestOf, 9), rules, 2)
+
+    sendAction(room, players[0], "ready")
+    sendAction(room, players[1], "ready")
+    fake.Advance(0)
+
+    fake.Advance(time.Duration(rules.MaxDuration)*time.Second - time.Millisecond)
+    if started, _, _ := roomStatus(room); !started {
+        t.Fatal("game ended before its maximum duration")
+    }
+
+    fake.Advance(time.Millisecond)
+    if started, round, countingDown := roomStatus(room); started || round != 0 || countingDown {
+        t.Fatalf("after the maximum duration: started %v, round %d, counting down %v", started, round, countingDown)
+    }
+}
+
+func TestMaxDurationCreditsCutShortRound(t *testing.T) {
+    rules := models.DefaultRoomRules()
+    rules.Countdown = 0
+    rules.MaxDuration = 5
+    room, fake, players := newTestRoom(t, mustMode(t, gamemode.LastBirdStanding, 0), rules, 2)
+    gameEnd := watchGameEnd(t, room)
+
+    sendAction(room, players[0], "ready")
+    sendAction(room, players[1], "ready")
+    fake.Advance(0)
+
+    // The first player keeps flapping and is still up when time runs out,
+    // long after the second one hit the ground
+    for elapsed := time.Duration(0); elapsed < time.Duration(rules.MaxDuration)*time.Second; elapsed += 500 * time.Millisecond {
+        sendAction(room, players[0], "flap")
+        fake.Advance(500 * time.Millisecond)
+    }
+    if started, _, _ := roomStatus(room); started {
+        t.Fatal("game kept running past its maximum duration")
+    }
+
+    select {
+    case winners := <-gameEnd:
+        if len(winners) != 1 || winners[0] != players[0].userIDString() {
+            t.Fatalf("winners %v, want only %s", winners, players[0].userIDString())
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("game end was never broadcast")
+    }
+}
+
+func TestLobbyTimeoutKicksIdlePlayers(t *testing.T) {
+    rules := models.DefaultRoomRules()
+    rules.LobbyTimeout = 60
+    room, fake, players := newTestRoom(t, mustMode(t, gamemode.LastBirdStanding, 0), rules, 2)
+
+    // Anything the player sends counts as activity
+    fake.Advance(30 * time.Second)
+    sendAction(room, players[1], "info")
+
+    fake.Advance(30*time.Second - time.Millisecond)
+    if players[0].currentRoom() != room {
+        t.Fatal("player was kicked before the lobby timeout")
+    }
+
+    fake.Advance(lobbyCheckInterval)
+    if players[0].currentRoom() != nil {
+        t.Fatal("idle player was not kicked")
+    }
+    if players[1].currentRoom() != room {
+        t.Fatal("active player was kicked")
+    }
+
+    // The kick is kept for the next game's session
+    room.sessionMutex.Lock()
+    defer room.sessionMutex.Unlock()
+    if len(room.lobbyActions) != 1 || room.lobbyActions[0].Action != "kick" || room.lobbyActions[0].Target != players[0].userIDString() {
+        t.Fatalf("recorded lobby actions %+v", room.lobbyActions)
+    }
+}
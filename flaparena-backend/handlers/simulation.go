@@ -0,0 +1,135 @@
+package handlers
+
+import (
+    "crypto/rand"
+    "encoding/binary"
+    "log"
+    "time"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+    "github.com/mapleleafu/flaparena/flaparena-backend/simulation"
+)
+
+// newCourseParams picks a fresh random seed for the next game's course.
+// Challenge attempts fly the day's course instead.
+func (room *Room) newCourseParams() simulation.CourseParams {
+    room.State.Mutex.Lock()
+    challenge := room.challenge
+    room.State.Mutex.Unlock()
+    if challenge != nil {
+        return room.Rules.CourseParams(challengeSeed(challenge.day))
+    }
+
+    var seed [4]byte
+    if _, err := rand.Read(seed[:]); err != nil {
+        log.Printf("Error generating course seed: %v", err)
+    }
+    return room.Rules.CourseParams(binary.BigEndian.Uint32(seed[:]))
+}
+
+// newGameWorld creates the server-side simulation for every ready player.
+func (room *Room) newGameWorld(course simulation.CourseParams) *simulation.World {
+    world := simulation.NewWorld(room.Rules.Physics(), simulation.NewCourse(course))
+    for userID, player := range room.State.Players {
+        if player.Ready {
+            world.AddBird(userID)
+        }
+    }
+    return world
+}
+
+// currentTick converts the time since the game started into a simulation tick.
+func (room *Room) currentTick() int64 {
+    elapsed := room.clock.Now().Sub(room.State.StartedAt)
+    return int64(elapsed / (time.Second / simulation.TicksPerSecond))
+}
+
+// syncSimulation advances the world to the present and applies every score
+// and death it produced to the game state.
+func (room *Room) syncSimulation() {
+    room.State.Mutex.Lock()
+    if room.State.World == nil {
+        room.State.Mutex.Unlock()
+        return
+    }
+    events := room.State.World.AdvanceTo(room.currentTick())
+    room.State.Mutex.Unlock()
+
+    for _, event := range events {
+        // A death may have ended the round part way through the events
+        room.State.Mutex.Lock()
+        running := room.State.Started && room.State.World != nil
+        startedAt := room.State.StartedAt
+        room.State.Mutex.Unlock()
+        if !running {
+            return
+        }
+        timestamp := startedAt.Add(time.Duration(event.Tick) * time.Second / simulation.TicksPerSecond).UnixMilli()
+        switch event.Type {
+        case simulation.EventScore:
+            room.playerScored(event.UserID)
+            room.broadcastMessage("playerScored", map[string]interface{}{"userID": event.UserID})
+            room.handleGameAction(models.GameAction{UserID: event.UserID, Action: "score", Timestamp: timestamp})
+        case simulation.EventDeath:
+            room.killPlayer(event.UserID, timestamp)
+        }
+    }
+}
+
+// simulateFlap applies a flap to the user's bird after bringing the world up to date.
+func (room *Room) simulateFlap(userID string) bool {
+    room.syncSimulation()
+
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+    if room.State.World == nil || !room.State.World.Flap(userID) {
+        return false
+    }
+    if player, exists := room.State.Players[userID]; exists {
+        player.Flaps++
+    }
+    return true
+}
+
+// isAlive reports whether the user's bird is still flying.
+func (room *Room) isAlive(userID string) bool {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    player, exists := room.State.Players[userID]
+    return exists && player.Alive
+}
+
+// killPlayer marks a player as dead in both the game state and the simulation.
+func (room *Room) killPlayer(userID string, timestamp int64) {
+    room.State.Mutex.Lock()
+    killed := room.markDead(userID)
+    room.State.Mutex.Unlock()
+
+    if killed {
+        room.announceDeath(userID, timestamp)
+    }
+}
+
+// markDead reports whether the player was alive. Callers must hold State.Mutex.
+func (room *Room) markDead(userID string) bool {
+    player, exists := room.State.Players[userID]
+    if !exists || !player.Alive {
+        return false
+    }
+    player.Alive = false
+    if room.State.World != nil {
+        room.State.World.Kill(userID)
+        player.DiedAt = room.State.World.Tick
+    }
+    return true
+}
+
+// announceDeath tells the room about a death and ends the round if the mode says so.
+func (room *Room) announceDeath(userID string, timestamp int64) {
+    room.broadcastMessage("playerDead", map[string]string{"userID": userID})
+    room.handleGameAction(models.GameAction{UserID: userID, Action: "dead", Timestamp: timestamp})
+    log.Printf("Player %s is dead", userID)
+
+    room.checkRoundOver()
+}
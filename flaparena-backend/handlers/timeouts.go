@@ -0,0 +1,143 @@
+package handlers
+
+import (
+    "log"
+    "time"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+)
+
+// lobbyCheckInterval is how often a room looks for players idling in its lobby.
+const lobbyCheckInterval = 5 * time.Second
+
+// touch records that a player just sent something.
+func (room *Room) touch(userID string) {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    if player, exists := room.State.Players[userID]; exists {
+        player.LastInput = room.clock.Now()
+    }
+}
+
+// killIdlePlayers kills the birds of players who haven't sent anything for
+// longer than the room's idle timeout.
+func (room *Room) killIdlePlayers() {
+    if room.Rules.IdleTimeout <= 0 {
+        return
+    }
+    timeout := time.Duration(room.Rules.IdleTimeout) * time.Second
+    now := room.clock.Now()
+
+    room.State.Mutex.Lock()
+    var idle []string
+    if room.State.Started && room.State.World != nil {
+        for userID, player := range room.State.Players {
+            if player.Alive && now.Sub(player.LastInput) >= timeout && room.markDead(userID) {
+                idle = append(idle, userID)
+            }
+        }
+    }
+    room.State.Mutex.Unlock()
+
+    for _, userID := range idle {
+        log.Printf("Player %s idled out in room %s", userID, room.ID)
+        room.handleGameAction(models.GameAction{
+            UserID:    "server",
+            Action:    "idleKill",
+            Timestamp: now.UnixMilli(),
+            Target:    userID,
+        })
+        room.announceDeath(userID, now.UnixMilli())
+    }
+}
+
+// watchLobby kicks players who sit in the room between games without
+// readying up for longer than the room's lobby timeout. It keeps itself
+// scheduled for the life of the room.
+func (room *Room) watchLobby() {
+    room.scheduler.After(lobbyCheckInterval, room.watchLobby)
+
+    timeout := time.Duration(room.Rules.LobbyTimeout) * time.Second
+    now := room.clock.Now()
+
+    room.State.Mutex.Lock()
+    var idle []*Connection
+    if !room.State.Started {
+        for userID, player := range room.State.Players {
+            if player.Ready || player.Bot || !player.Connected || now.Sub(player.LastInput) < timeout {
+                continue
+            }
+            if c, exists := room.members[userID]; exists {
+                idle = append(idle, c)
+            }
+        }
+    }
+    room.State.Mutex.Unlock()
+
+    for _, c := range idle {
+        room.kick(c, "lobbyTimeout")
+    }
+}
+
+// kick takes a connection out of the room for good and closes it.
+func (room *Room) kick(c *Connection, reason string) {
+    c.roomMutex.Lock()
+    if c.room != room {
+        c.roomMutex.Unlock()
+        return
+    }
+    c.room = nil
+    c.sendMessage("kicked", map[string]string{"roomID": room.ID, "reason": reason})
+    room.removeConnection(c, false)
+    c.roomMutex.Unlock()
+
+    room.handleLobbyAction(models.GameAction{
+        UserID:    "server",
+        Action:    "kick",
+        Timestamp: room.clock.Now().UnixMilli(),
+        Target:    c.userIDString(),
+    })
+    log.Printf("User %d kicked from room %s: %s", c.userID, room.ID, reason)
+    c.close()
+}
+
+// timeoutGame ends the game gameID once it has run for the room's maximum
+// duration. A round that is already ending is left to finish; nextRound
+// then notices the deadline has passed.
+func (room *Room) timeoutGame(gameID string) {
+    room.State.Mutex.Lock()
+    if !room.State.Started || room.State.GameID != gameID {
+        room.State.Mutex.Unlock()
+        return
+    }
+    inRound := !room.roundEnded
+    inBreak := room.roundEnded && room.stopCountdown()
+    if inRound {
+        // The round is cut short, but what was played of it still counts,
+        // including who was still flying when time ran out
+        room.settleRound()
+    }
+    room.roundEnded = true
+    room.State.Mutex.Unlock()
+
+    if inRound || inBreak {
+        room.endTimedOutGame()
+    }
+}
+
+// endTimedOutGame records why the game stopped early and ends it.
+func (room *Room) endTimedOutGame() {
+    room.State.Mutex.Lock()
+    gameID := room.State.GameID
+    room.State.Mutex.Unlock()
+
+    log.Printf("Game %s in room %s reached its maximum duration", gameID, room.ID)
+    room.handleGameAction(models.GameAction{
+        UserID:    "server",
+        Action:    "timeout",
+        Timestamp: room.clock.Now().UnixMilli(),
+    })
+    room.broadcastMessage("gameTimeout", map[string]string{"gameID": gameID})
+    room.endGame()
+}
@@ -0,0 +1,367 @@
+package handlers
+
+import (
+    "database/sql"
+    "log"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+    "github.com/mapleleafu/flaparena/flaparena-backend/repository"
+)
+
+// singleEliminationMatches lays out every match of a knockout bracket for
+// players, who are given best seed first. The bracket is padded to a power
+// of two with byes, which go to the top seeds and are decided straight away.
+func singleEliminationMatches(players []string) []models.TournamentMatch {
+    size := 2
+    for size < len(players) {
+        size *= 2
+    }
+
+    // Standard seeding keeps the top seeds apart until the late rounds
+    order := []int{0}
+    for len(order) < size {
+        next := make([]int, 0, len(order)*2)
+        for _, seed := range order {
+            next = append(next, seed, len(order)*2-1-seed)
+        }
+        order = next
+    }
+
+    var matches []models.TournamentMatch
+    rounds := make([][]*models.TournamentMatch, 0)
+    for round, count := 1, size/2; count >= 1; round, count = round+1, count/2 {
+        roundMatches := make([]*models.TournamentMatch, count)
+        for position := range roundMatches {
+            roundMatches[position] = &models.TournamentMatch{
+                ID:       uuid.New().String(),
+                Round:    round,
+                Position: position,
+                Status:   models.MatchPending,
+            }
+        }
+        rounds = append(rounds, roundMatches)
+    }
+
+    for position, match := range rounds[0] {
+        if seed := order[position*2]; seed < len(players) {
+            match.Player1ID = players[seed]
+        }
+        if seed := order[position*2+1]; seed < len(players) {
+            match.Player2ID = players[seed]
+        }
+
+        // A bye: the lone player goes through to the second round
+        if match.Player1ID == "" || match.Player2ID == "" {
+            match.WinnerID = match.Player1ID + match.Player2ID
+            match.Status = models.MatchFinished
+            if len(rounds) > 1 {
+                seatPlayer(rounds[1][position/2], position, match.WinnerID)
+            }
+        }
+    }
+
+    for _, roundMatches := range rounds {
+        for _, match := range roundMatches {
+            matches = append(matches, *match)
+        }
+    }
+    return matches
+}
+
+// seatPlayer puts the winner of the match at position in the previous round
+// into the match it feeds.
+func seatPlayer(match *models.TournamentMatch, position int, userID string) {
+    if position%2 == 0 {
+        match.Player1ID = userID
+    } else {
+        match.Player2ID = userID
+    }
+}
+
+// roundRobinMatches pairs every player with every other player once, spread
+// over rounds with the circle method so nobody plays twice in a round.
+func roundRobinMatches(players []string) []models.TournamentMatch {
+    circle := append([]string(nil), players...)
+    if len(circle)%2 == 1 {
+        circle = append(circle, "") // Whoever meets the gap sits the round out
+    }
+
+    var matches []models.TournamentMatch
+    for round := 1; round < len(circle); round++ {
+        position := 0
+        for i := 0; i < len(circle)/2; i++ {
+            player1, player2 := circle[i], circle[len(circle)-1-i]
+            if player1 == "" || player2 == "" {
+                continue
+            }
+            matches = append(matches, models.TournamentMatch{
+                ID:        uuid.New().String(),
+                Round:     round,
+                Position:  position,
+                Player1ID: player1,
+                Player2ID: player2,
+                Status:    models.MatchPending,
+            })
+            position++
+        }
+
+        // Keep the first player in place and rotate everyone else
+        last := circle[len(circle)-1]
+        copy(circle[2:], circle[1:len(circle)-1])
+        circle[1] = last
+    }
+    return matches
+}
+
+// insertTournamentMatches stores a freshly generated bracket.
+func insertTournamentMatches(tx *sql.Tx, tournamentID string, matches []models.TournamentMatch) error {
+    for _, match := range matches {
+        _, err := tx.Exec(`INSERT INTO tournament_matches (id, tournament_id, round, position, player1_id, player2_id, winner_id, status)
+            VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8)`,
+            match.ID, tournamentID, match.Round, match.Position, match.Player1ID, match.Player2ID, match.WinnerID, match.Status)
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// openReadyMatches creates a room for every match of the tournament whose
+// two players are known. Only the two of them can play in it; anyone else
+// with the invite code joins as a spectator.
+func openReadyMatches(tournamentID string) {
+    db := repository.PostgreSQLDB
+
+    var ownerID, modeName string
+    var rounds, timeLimit int
+    err := db.QueryRow("SELECT owner_id, mode, rounds, time_limit FROM tournaments WHERE id = $1 AND status = $2",
+        tournamentID, models.TournamentRunning).Scan(&ownerID, &modeName, &rounds, &timeLimit)
+    if err == sql.ErrNoRows {
+        return
+    } else if err != nil {
+        log.Printf("Error fetching tournament %s: %v", tournamentID, err)
+        return
+    }
+
+    rows, err := db.Query(`SELECT id, player1_id, player2_id FROM tournament_matches
+        WHERE tournament_id = $1 AND status = $2 AND player1_id IS NOT NULL AND player2_id IS NOT NULL`,
+        tournamentID, models.MatchPending)
+    if err != nil {
+        log.Printf("Error fetching matches of tournament %s: %v", tournamentID, err)
+        return
+    }
+    var ready []models.TournamentMatch
+    for rows.Next() {
+        var match models.TournamentMatch
+        if err := rows.Scan(&match.ID, &match.Player1ID, &match.Player2ID); err != nil {
+            log.Printf("Error reading match of tournament %s: %v", tournamentID, err)
+            rows.Close()
+            return
+        }
+        ready = append(ready, match)
+    }
+    rows.Close()
+
+    rules := models.DefaultRoomRules()
+    rules.MaxPlayers = 2
+    for _, match := range ready {
+        mode, err := gamemode.New(modeName, rounds, time.Duration(timeLimit)*time.Second)
+        if err != nil {
+            log.Printf("Tournament %s has an invalid mode: %v", tournamentID, err)
+            return
+        }
+
+        room, err := rooms.CreatePrivate(ownerID, mode, rules)
+        if err != nil {
+            log.Printf("Error creating room for tournament match %s: %v", match.ID, err)
+            continue
+        }
+        room.holdTournamentMatch(match.ID, match.Player1ID, match.Player2ID)
+
+        result, err := db.Exec("UPDATE tournament_matches SET status = $1, room_id = $2, invite_code = $3 WHERE id = $4 AND status = $5",
+            models.MatchPlaying, room.ID, room.InviteCode, match.ID, models.MatchPending)
+        var opened int64
+        if err == nil {
+            opened, err = result.RowsAffected()
+        }
+        if err != nil || opened == 0 {
+            // Opened elsewhere in the meantime, or not at all
+            if err != nil {
+                log.Printf("Error opening tournament match %s: %v", match.ID, err)
+            }
+            room.holdTournamentMatch("")
+            rooms.RemoveIfEmpty(room)
+            continue
+        }
+        log.Printf("Opened room %s for tournament match %s", room.ID, match.ID)
+    }
+}
+
+// recordTournamentResult feeds the outcome of a finished game into the
+// bracket. A game without a single winner among the match's two players
+// decides nothing and the match is played again. It reports whether the
+// match was decided.
+func recordTournamentResult(matchID, gameID string, winners []string) bool {
+    db := repository.PostgreSQLDB
+    tx, err := db.Begin()
+    if err != nil {
+        log.Printf("Error starting transaction for tournament match %s: %v", matchID, err)
+        return false
+    }
+    defer tx.Rollback()
+
+    var tournamentID, player1ID, player2ID, status, format string
+    var round, position int
+    err = tx.QueryRow(`SELECT m.tournament_id, m.round, m.position, m.player1_id, m.player2_id, m.status, t.format
+        FROM tournament_matches m JOIN tournaments t ON t.id = m.tournament_id
+        WHERE m.id = $1 FOR UPDATE`, matchID).Scan(&tournamentID, &round, &position, &player1ID, &player2ID, &status, &format)
+    if err != nil {
+        log.Printf("Error fetching tournament match %s: %v", matchID, err)
+        return false
+    }
+    if status != models.MatchPlaying {
+        return false
+    }
+
+    var winnerID string
+    for _, userID := range winners {
+        if userID == player1ID || userID == player2ID {
+            if winnerID != "" {
+                winnerID = "" // A tie
+                break
+            }
+            winnerID = userID
+        }
+    }
+    if winnerID == "" {
+        log.Printf("Tournament match %s had no single winner, playing it again", matchID)
+        return false
+    }
+
+    _, err = tx.Exec("UPDATE tournament_matches SET winner_id = $1, status = $2, game_id = $3 WHERE id = $4",
+        winnerID, models.MatchFinished, gameID, matchID)
+    if err != nil {
+        log.Printf("Error finishing tournament match %s: %v", matchID, err)
+        return false
+    }
+
+    switch format {
+    case models.SingleElimination:
+        err = advanceWinner(tx, tournamentID, round, position, winnerID)
+    case models.RoundRobin:
+        err = finishRoundRobinIfDone(tx, tournamentID)
+    }
+    if err != nil {
+        log.Printf("Error advancing tournament %s: %v", tournamentID, err)
+        return false
+    }
+
+    if err := tx.Commit(); err != nil {
+        log.Printf("Error committing tournament match %s: %v", matchID, err)
+        return false
+    }
+    log.Printf("User %s won tournament match %s", winnerID, matchID)
+
+    openReadyMatches(tournamentID)
+    return true
+}
+
+// reportTournamentResult passes the winners of the game that just ended to
+// the tournament match the room was opened for. Once the match is decided
+// the room becomes an ordinary private room.
+func (room *Room) reportTournamentResult(gameID string, winners []string) {
+    matchID := room.tournamentMatchID()
+    if matchID == "" || gameID == "" {
+        return
+    }
+    if !recordTournamentResult(matchID, gameID, winners) {
+        room.broadcastMessage("tournamentMatchReplay", map[string]string{"matchID": matchID})
+        return
+    }
+
+    room.holdTournamentMatch("")
+    room.broadcastMessage("tournamentMatchEnd", map[string]interface{}{"matchID": matchID, "winners": winners})
+}
+
+// advanceWinner moves the winner of a knockout match on to the next round,
+// or crowns them if that was the final.
+func advanceWinner(tx *sql.Tx, tournamentID string, round, position int, winnerID string) error {
+    column := "player1_id"
+    if position%2 == 1 {
+        column = "player2_id"
+    }
+    result, err := tx.Exec("UPDATE tournament_matches SET "+column+" = $1 WHERE tournament_id = $2 AND round = $3 AND position = $4",
+        winnerID, tournamentID, round+1, position/2)
+    if err != nil {
+        return err
+    }
+    if advanced, err := result.RowsAffected(); err != nil || advanced > 0 {
+        return err
+    }
+    return finishTournament(tx, tournamentID, winnerID)
+}
+
+// finishRoundRobinIfDone crowns the player with the most wins once every
+// match has been played. Ties go to the better seed.
+func finishRoundRobinIfDone(tx *sql.Tx, tournamentID string) error {
+    var remaining int
+    err := tx.QueryRow("SELECT COUNT(*) FROM tournament_matches WHERE tournament_id = $1 AND status != $2",
+        tournamentID, models.MatchFinished).Scan(&remaining)
+    if err != nil || remaining > 0 {
+        return err
+    }
+
+    var winnerID string
+    err = tx.QueryRow(`SELECT p.user_id FROM tournament_players p
+        LEFT JOIN tournament_matches m ON m.tournament_id = p.tournament_id AND m.winner_id = p.user_id
+        WHERE p.tournament_id = $1
+        GROUP BY p.user_id, p.seed
+        ORDER BY COUNT(m.id) DESC, p.seed ASC
+        LIMIT 1`, tournamentID).Scan(&winnerID)
+    if err != nil {
+        return err
+    }
+    return finishTournament(tx, tournamentID, winnerID)
+}
+
+func finishTournament(tx *sql.Tx, tournamentID, winnerID string) error {
+    _, err := tx.Exec("UPDATE tournaments SET status = $1, winner_id = $2 WHERE id = $3",
+        models.TournamentFinished, winnerID, tournamentID)
+    if err == nil {
+        log.Printf("User %s won tournament %s", winnerID, tournamentID)
+    }
+    return err
+}
+
+// reopenTournamentMatches gives every match that was being played when the
+// server stopped a new room, since rooms don't survive a restart.
+func reopenTournamentMatches() {
+    db := repository.PostgreSQLDB
+    _, err := db.Exec("UPDATE tournament_matches SET status = $1, room_id = NULL, invite_code = NULL WHERE status = $2",
+        models.MatchPending, models.MatchPlaying)
+    if err != nil {
+        log.Printf("Error resetting tournament matches: %v", err)
+        return
+    }
+
+    rows, err := db.Query("SELECT id FROM tournaments WHERE status = $1", models.TournamentRunning)
+    if err != nil {
+        log.Printf("Error fetching running tournaments: %v", err)
+        return
+    }
+    var tournamentIDs []string
+    for rows.Next() {
+        var tournamentID string
+        if err := rows.Scan(&tournamentID); err == nil {
+            tournamentIDs = append(tournamentIDs, tournamentID)
+        }
+    }
+    rows.Close()
+
+    for _, tournamentID := range tournamentIDs {
+        openReadyMatches(tournamentID)
+    }
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+    "fmt"
+    "reflect"
+    "testing"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+)
+
+func seededPlayers(count int) []string {
+    players := make([]string, count)
+    for i := range players {
+        players[i] = fmt.Sprintf("p%d", i+1)
+    }
+    return players
+}
+
+// describeMatch writes a match as "round.position player1-player2 >winner".
+func describeMatch(match models.TournamentMatch) string {
+    description := fmt.Sprintf("%d.%d %s-%s", match.Round, match.Position, match.Player1ID, match.Player2ID)
+    if match.WinnerID != "" {
+        description += " >" + match.WinnerID
+    }
+    return description
+}
+
+func TestSingleEliminationMatches(t *testing.T) {
+    tests := []struct {
+        players int
+        want    []string
+    }{
+        {2, []string{
+            "1.0 p1-p2",
+        }},
+        {3, []string{
+            "1.0 p1- >p1", "1.1 p2-p3",
+            "2.0 p1-",
+        }},
+        {4, []string{
+            "1.0 p1-p4", "1.1 p2-p3",
+            "2.0 -",
+        }},
+        {5, []string{
+            "1.0 p1- >p1", "1.1 p4-p5", "1.2 p2- >p2", "1.3 p3- >p3",
+            "2.0 p1-", "2.1 p2-p3",
+            "3.0 -",
+        }},
+        {6, []string{
+            "1.0 p1- >p1", "1.1 p4-p5", "1.2 p2- >p2", "1.3 p3-p6",
+            "2.0 p1-", "2.1 p2-",
+            "3.0 -",
+        }},
+        {8, []string{
+            "1.0 p1-p8", "1.1 p4-p5", "1.2 p2-p7", "1.3 p3-p6",
+            "2.0 -", "2.1 -",
+            "3.0 -",
+        }},
+    }
+
+    for _, test := range tests {
+        t.Run(fmt.Sprintf("%d players", test.players), func(t *testing.T) {
+            matches := singleEliminationMatches(seededPlayers(test.players))
+
+            var got []string
+            ids := make(map[string]bool)
+            for _, match := range matches {
+                got = append(got, describeMatch(match))
+
+                // Byes are decided straight away, every other match waits
+                wantStatus := models.MatchPending
+                if match.WinnerID != "" {
+                    wantStatus = models.MatchFinished
+                }
+                if match.Status != wantStatus {
+                    t.Errorf("match %s is %s, want %s", describeMatch(match), match.Status, wantStatus)
+                }
+                if match.ID == "" || ids[match.ID] {
+                    t.Errorf("match %s has a missing or repeated ID %q", describeMatch(match), match.ID)
+                }
+                ids[match.ID] = true
+            }
+            if !reflect.DeepEqual(got, test.want) {
+                t.Fatalf("bracket\n%q\nwant\n%q", got, test.want)
+            }
+        })
+    }
+}
+
+func TestSeatPlayer(t *testing.T) {
+    var match models.TournamentMatch
+    seatPlayer(&match, 2, "p1")
+    seatPlayer(&match, 3, "p2")
+    if match.Player1ID != "p1" || match.Player2ID != "p2" {
+        t.Fatalf("winners of positions 2 and 3 were seated as %q and %q", match.Player1ID, match.Player2ID)
+    }
+}
+
+func TestRoundRobinMatches(t *testing.T) {
+    for players := 2; players <= 7; players++ {
+        t.Run(fmt.Sprintf("%d players", players), func(t *testing.T) {
+            seeded := seededPlayers(players)
+            matches := roundRobinMatches(seeded)
+
+            // An odd field adds a round, since someone sits each one out
+            wantRounds := players - 1
+            if players%2 == 1 {
+                wantRounds = players
+            }
+
+            pairings := make(map[[2]string]int)
+            playing := make(map[int]map[string]bool)
+            positions := make(map[int]int)
+            for _, match := range matches {
+                if match.Round < 1 || match.Round > wantRounds {
+                    t.Fatalf("match %s is outside rounds 1 to %d", describeMatch(match), wantRounds)
+                }
+                if match.Status != models.MatchPending || match.WinnerID != "" {
+                    t.Fatalf("match %s starts %s", describeMatch(match), match.Status)
+                }
+                if match.Position != positions[match.Round] {
+                    t.Fatalf("match %s, want position %d", describeMatch(match), positions[match.Round])
+                }
+                positions[match.Round]++
+
+                if playing[match.Round] == nil {
+                    playing[match.Round] = make(map[string]bool)
+                }
+                for _, userID := range []string{match.Player1ID, match.Player2ID} {
+                    if userID == "" || playing[match.Round][userID] {
+                        t.Fatalf("%q plays twice or not at all in round %d", userID, match.Round)
+                    }
+                    playing[match.Round][userID] = true
+                }
+
+                pairing := [2]string{match.Player1ID, match.Player2ID}
+                if pairing[0] > pairing[1] {
+                    pairing[0], pairing[1] = pairing[1], pairing[0]
+                }
+                pairings[pairing]++
+            }
+
+            if len(matches) != players*(players-1)/2 {
+                t.Fatalf("%d matches, want %d", len(matches), players*(players-1)/2)
+            }
+            for i, player1 := range seeded {
+                for _, player2 := range seeded[i+1:] {
+                    if count := pairings[[2]string{player1, player2}]; count != 1 {
+                        t.Fatalf("%s and %s meet %d times", player1, player2, count)
+                    }
+                }
+            }
+        })
+    }
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+    "database/sql"
+    "encoding/json"
+    "log"
+    "math/rand"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/gorilla/mux"
+    "github.com/mapleleafu/flaparena/flaparena-backend/common"
+    "github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+    "github.com/mapleleafu/flaparena/flaparena-backend/repository"
+    "github.com/mapleleafu/flaparena/flaparena-backend/responses"
+    "github.com/mapleleafu/flaparena/flaparena-backend/utils"
+)
+
+// CreateTournament opens a tournament for registration. The caller owns it
+// and is the only one who can start it.
+func CreateTournament(w http.ResponseWriter, r *http.Request) {
+    authInfo, ok := r.Context().Value(common.AuthInfoKey).(*models.CustomClaims)
+    if !ok {
+        utils.HandleError(w, responses.InternalServerError{Msg: "Error processing request."})
+        return
+    }
+
+    var request models.CreateTournamentRequest
+    if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+        utils.HandleError(w, responses.BadRequestError{Msg: "Invalid request."})
+        return
+    }
+
+    request.Name = strings.TrimSpace(request.Name)
+    if request.Name == "" {
+        utils.HandleError(w, responses.BadRequestError{Msg: "name is required."})
+        return
+    }
+    if request.Format != models.SingleElimination && request.Format != models.RoundRobin {
+        utils.HandleError(w, responses.BadRequestError{Msg: "format must be singleElimination or roundRobin."})
+        return
+    }
+    mode, err := gamemode.New(request.Mode, request.Rounds, time.Duration(request.TimeLimit)*time.Second)
+    if err != nil {
+        utils.HandleError(w, responses.BadRequestError{Msg: err.Error()})
+        return
+    }
+    info := mode.Info()
+    if info.Name == gamemode.Practice {
+        utils.HandleError(w, responses.BadRequestError{Msg: "Tournaments can't be played in practice mode."})
+        return
+    }
+
+    tournamentID := uuid.New().String()
+    db := repository.PostgreSQLDB
+    _, err = db.Exec(`INSERT INTO tournaments (id, name, format, owner_id, status, mode, rounds, time_limit)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+        tournamentID, request.Name, request.Format, authInfo.ID, models.TournamentRegistration, info.Name, info.Rounds, info.TimeLimit)
+    if err != nil {
+        log.Printf("Error creating tournament: %v", err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to create tournament."})
+        return
+    }
+
+    writeTournament(w, tournamentID)
+}
+
+func ListTournaments(w http.ResponseWriter, r *http.Request) {
+    db := repository.PostgreSQLDB
+    rows, err := db.Query(`SELECT id, name, format, owner_id, status, mode, rounds, time_limit, COALESCE(winner_id, ''), created_at
+        FROM tournaments ORDER BY created_at DESC`)
+    if err != nil {
+        log.Printf("Error fetching tournaments: %v", err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to fetch tournaments."})
+        return
+    }
+    defer rows.Close()
+
+    tournaments := make([]models.Tournament, 0)
+    for rows.Next() {
+        var tournament models.Tournament
+        err := rows.Scan(&tournament.ID, &tournament.Name, &tournament.Format, &tournament.OwnerID, &tournament.Status,
+            &tournament.Mode, &tournament.Rounds, &tournament.TimeLimit, &tournament.WinnerID, &tournament.CreatedAt)
+        if err != nil {
+            utils.HandleError(w, responses.InternalServerError{Msg: "Error processing tournaments."})
+            return
+        }
+        tournaments = append(tournaments, tournament)
+    }
+
+    if err = rows.Err(); err != nil {
+        log.Printf("Error iterating tournament rows: %v", err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Error processing tournaments."})
+        return
+    }
+
+    utils.HandleSuccess(w, models.SuccessResponse(tournaments))
+}
+
+// GetTournament returns a tournament with its players and bracket.
+func GetTournament(w http.ResponseWriter, r *http.Request) {
+    writeTournament(w, mux.Vars(r)["tournamentID"])
+}
+
+// RegisterForTournament signs the caller up for a tournament that hasn't started.
+func RegisterForTournament(w http.ResponseWriter, r *http.Request) {
+    authInfo, ok := r.Context().Value(common.AuthInfoKey).(*models.CustomClaims)
+    if !ok {
+        utils.HandleError(w, responses.InternalServerError{Msg: "Error processing request."})
+        return
+    }
+
+    tournamentID := mux.Vars(r)["tournamentID"]
+    db := repository.PostgreSQLDB
+    result, err := db.Exec(`INSERT INTO tournament_players (tournament_id, user_id)
+        SELECT id, $2 FROM tournaments WHERE id = $1 AND status = $3
+        ON CONFLICT DO NOTHING`,
+        tournamentID, authInfo.ID, models.TournamentRegistration)
+    if err != nil {
+        log.Printf("Error registering for tournament %s: %v", tournamentID, err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to register for tournament."})
+        return
+    }
+
+    if registered, _ := result.RowsAffected(); registered == 0 {
+        var status string
+        err := db.QueryRow("SELECT status FROM tournaments WHERE id = $1", tournamentID).Scan(&status)
+        if err == sql.ErrNoRows {
+            utils.HandleError(w, responses.NotFoundError{Msg: "Tournament not found."})
+            return
+        }
+        if status != models.TournamentRegistration {
+            utils.HandleError(w, responses.BadRequestError{Msg: "Registration for this tournament is closed."})
+            return
+        }
+        // Already registered
+    }
+
+    writeTournament(w, tournamentID)
+}
+
+// StartTournament closes registration, seeds the players at random,
+// generates the bracket and opens a room for every match that can be played.
+func StartTournament(w http.ResponseWriter, r *http.Request) {
+    authInfo, ok := r.Context().Value(common.AuthInfoKey).(*models.CustomClaims)
+    if !ok {
+        utils.HandleError(w, responses.InternalServerError{Msg: "Error processing request."})
+        return
+    }
+
+    tournamentID := mux.Vars(r)["tournamentID"]
+    db := repository.PostgreSQLDB
+    tx, err := db.Begin()
+    if err != nil {
+        log.Printf("Error starting transaction: %v", err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to start tournament."})
+        return
+    }
+    defer tx.Rollback()
+
+    var ownerID, format, status string
+    err = tx.QueryRow("SELECT owner_id, format, status FROM tournaments WHERE id = $1 FOR UPDATE", tournamentID).
+        Scan(&ownerID, &format, &status)
+    if err == sql.ErrNoRows {
+        utils.HandleError(w, responses.NotFoundError{Msg: "Tournament not found."})
+        return
+    } else if err != nil {
+        log.Printf("Error fetching tournament %s: %v", tournamentID, err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to start tournament."})
+        return
+    }
+    if ownerID != authInfo.ID {
+        utils.HandleError(w, responses.ForbiddenError{Msg: "Only the owner can start the tournament."})
+        return
+    }
+    if status != models.TournamentRegistration {
+        utils.HandleError(w, responses.BadRequestError{Msg: "Tournament has already started."})
+        return
+    }
+
+    players, err := seedTournamentPlayers(tx, tournamentID)
+    if err != nil {
+        log.Printf("Error seeding tournament %s: %v", tournamentID, err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to start tournament."})
+        return
+    }
+    if len(players) < 2 {
+        utils.HandleError(w, responses.BadRequestError{Msg: "A tournament needs at least 2 players."})
+        return
+    }
+
+    var matches []models.TournamentMatch
+    if format == models.SingleElimination {
+        matches = singleEliminationMatches(players)
+    } else {
+        matches = roundRobinMatches(players)
+    }
+    if err := insertTournamentMatches(tx, tournamentID, matches); err != nil {
+        log.Printf("Error creating bracket for tournament %s: %v", tournamentID, err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to start tournament."})
+        return
+    }
+
+    _, err = tx.Exec("UPDATE tournaments SET status = $1 WHERE id = $2", models.TournamentRunning, tournamentID)
+    if err == nil {
+        err = tx.Commit()
+    }
+    if err != nil {
+        log.Printf("Error starting tournament %s: %v", tournamentID, err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to start tournament."})
+        return
+    }
+
+    log.Printf("Tournament %s started with %d players", tournamentID, len(players))
+    openReadyMatches(tournamentID)
+    writeTournament(w, tournamentID)
+}
+
+// seedTournamentPlayers shuffles the registered players and stores their
+// seeds. It returns the players best seed first.
+func seedTournamentPlayers(tx *sql.Tx, tournamentID string) ([]string, error) {
+    rows, err := tx.Query("SELECT user_id FROM tournament_players WHERE tournament_id = $1 ORDER BY registered_at", tournamentID)
+    if err != nil {
+        return nil, err
+    }
+    var players []string
+    for rows.Next() {
+        var userID string
+        if err := rows.Scan(&userID); err != nil {
+            rows.Close()
+            return nil, err
+        }
+        players = append(players, userID)
+    }
+    rows.Close()
+
+    random := rand.New(rand.NewSource(time.Now().UnixNano()))
+    random.Shuffle(len(players), func(i, j int) { players[i], players[j] = players[j], players[i] })
+
+    for i, userID := range players {
+        _, err := tx.Exec("UPDATE tournament_players SET seed = $1 WHERE tournament_id = $2 AND user_id = $3",
+            i+1, tournamentID, userID)
+        if err != nil {
+            return nil, err
+        }
+    }
+    return players, nil
+}
+
+// writeTournament responds with the tournament, its players and its bracket.
+func writeTournament(w http.ResponseWriter, tournamentID string) {
+    tournament, err := loadTournament(tournamentID)
+    if err == sql.ErrNoRows {
+        utils.HandleError(w, responses.NotFoundError{Msg: "Tournament not found."})
+        return
+    } else if err != nil {
+        log.Printf("Error fetching tournament %s: %v", tournamentID, err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to fetch tournament."})
+        return
+    }
+
+    utils.HandleSuccess(w, models.SuccessResponse(tournament))
+}
+
+func loadTournament(tournamentID string) (models.Tournament, error) {
+    db := repository.PostgreSQLDB
+    var tournament models.Tournament
+    err := db.QueryRow(`SELECT id, name, format, owner_id, status, mode, rounds, time_limit, COALESCE(winner_id, ''), created_at
+        FROM tournaments WHERE id = $1`, tournamentID).
+        Scan(&tournament.ID, &tournament.Name, &tournament.Format, &tournament.OwnerID, &tournament.Status,
+            &tournament.Mode, &tournament.Rounds, &tournament.TimeLimit, &tournament.WinnerID, &tournament.CreatedAt)
+    if err != nil {
+        return tournament, err
+    }
+
+    playerRows, err := db.Query(`SELECT p.user_id, COALESCE(u.username, ''), p.seed,
+            (SELECT COUNT(*) FROM tournament_matches m WHERE m.tournament_id = p.tournament_id AND m.winner_id = p.user_id
+                AND m.player1_id IS NOT NULL AND m.player2_id IS NOT NULL)
+        FROM tournament_players p LEFT JOIN users u ON u.id::text = p.user_id
+        WHERE p.tournament_id = $1 ORDER BY p.seed, p.registered_at`, tournamentID)
+    if err != nil {
+        return tournament, err
+    }
+    defer playerRows.Close()
+
+    tournament.Players = make([]models.TournamentPlayer, 0)
+    for playerRows.Next() {
+        var player models.TournamentPlayer
+        if err := playerRows.Scan(&player.UserID, &player.Username, &player.Seed, &player.Wins); err != nil {
+            return tournament, err
+        }
+        tournament.Players = append(tournament.Players, player)
+    }
+    if err := playerRows.Err(); err != nil {
+        return tournament, err
+    }
+
+    matchRows, err := db.Query(`SELECT id, round, position, COALESCE(player1_id, ''), COALESCE(player2_id, ''),
+            COALESCE(winner_id, ''), status, COALESCE(room_id, ''), COALESCE(invite_code, ''), COALESCE(game_id, '')
+        FROM tournament_matches WHERE tournament_id = $1 ORDER BY round, position`, tournamentID)
+    if err != nil {
+        return tournament, err
+    }
+    defer matchRows.Close()
+
+    tournament.Matches = make([]models.TournamentMatch, 0)
+    for matchRows.Next() {
+        var match models.TournamentMatch
+        err := matchRows.Scan(&match.ID, &match.Round, &match.Position, &match.Player1ID, &match.Player2ID,
+            &match.WinnerID, &match.Status, &match.RoomID, &match.InviteCode, &match.GameID)
+        if err != nil {
+            return tournament, err
+        }
+        tournament.Matches = append(tournament.Matches, match)
+    }
+    return tournament, matchRows.Err()
+}
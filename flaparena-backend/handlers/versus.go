@@ -0,0 +1,125 @@
+package handlers
+
+import (
+    "database/sql"
+    "fmt"
+    "log"
+    "net/http"
+    "strconv"
+
+    "github.com/gorilla/mux"
+    "github.com/mapleleafu/flaparena/flaparena-backend/models"
+    "github.com/mapleleafu/flaparena/flaparena-backend/repository"
+    "github.com/mapleleafu/flaparena/flaparena-backend/responses"
+    "github.com/mapleleafu/flaparena/flaparena-backend/utils"
+)
+
+// GetVersus returns the head-to-head record of two users along with the
+// games they played together, latest first. The games are paged with
+// ?limit= and the returned next_cursor.
+func GetVersus(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    userID, err := strconv.ParseUint(vars["userID"], 10, 64)
+    if err != nil {
+        utils.HandleError(w, responses.BadRequestError{Msg: "Invalid user ID."})
+        return
+    }
+    otherID, err := strconv.ParseUint(vars["otherID"], 10, 64)
+    if err != nil {
+        utils.HandleError(w, responses.BadRequestError{Msg: "Invalid opponent ID."})
+        return
+    }
+    if userID == otherID {
+        utils.HandleError(w, responses.BadRequestError{Msg: "A user can't play against themselves."})
+        return
+    }
+    limit, err := pageLimit(r)
+    if err != nil {
+        utils.HandleError(w, responses.BadRequestError{Msg: err.Error()})
+        return
+    }
+
+    versus, err := loadVersus(userID, otherID, r.URL.Query().Get("cursor"), limit)
+    if err == sql.ErrNoRows {
+        utils.HandleError(w, responses.NotFoundError{Msg: "User not found."})
+        return
+    } else if err == errInvalidCursor {
+        utils.HandleError(w, responses.BadRequestError{Msg: "Invalid cursor."})
+        return
+    } else if err != nil {
+        log.Printf("Error fetching versus record of users %d and %d: %v", userID, otherID, err)
+        utils.HandleError(w, responses.InternalServerError{Msg: "Failed to fetch versus record."})
+        return
+    }
+
+    utils.HandleSuccess(w, models.SuccessResponse(versus))
+}
+
+// loadVersus sums up every game the two users shared and lists the page of
+// them after cursor.
+func loadVersus(userID, otherID uint64, cursor string, limit int) (models.Versus, error) {
+    db := repository.PostgreSQLDB
+    versus := models.Versus{
+        UserID:     strconv.FormatUint(userID, 10),
+        OpponentID: strconv.FormatUint(otherID, 10),
+        Matches:    make([]models.VersusGame, 0, limit),
+    }
+    err := db.QueryRow("SELECT a.username, b.username FROM users a, users b WHERE a.id = $1 AND b.id = $2", userID, otherID).
+        Scan(&versus.Username, &versus.OpponentUsername)
+    if err != nil {
+        return versus, err
+    }
+
+    // Both players' results of every game they shared
+    const together = `FROM game_results a JOIN game_results b ON b.game_id = a.game_id AND b.user_id = $2
+        WHERE a.user_id = $1`
+    err = db.QueryRow(`SELECT COUNT(*),
+            COUNT(*) FILTER (WHERE a.placement < b.placement),
+            COUNT(*) FILTER (WHERE a.placement > b.placement),
+            COUNT(*) FILTER (WHERE a.placement = b.placement),
+            COALESCE(AVG(a.score - b.score), 0) `+together, versus.UserID, versus.OpponentID).
+        Scan(&versus.Games, &versus.Wins, &versus.Losses, &versus.Draws, &versus.AverageScoreDiff)
+    if err != nil || versus.Games == 0 {
+        return versus, err
+    }
+
+    // Games are ordered by when they finished, then game ID, which the cursor points into
+    const key = "(EXTRACT(EPOCH FROM a.finished_at) * 1000000)::bigint"
+    query := `SELECT a.game_id, a.mode, a.finished_at, a.score, a.placement, b.score, b.placement ` + together
+    args := []interface{}{versus.UserID, versus.OpponentID}
+    if cursor != "" {
+        value, gameID, err := decodeCursor(cursor)
+        if err != nil {
+            return versus, errInvalidCursor
+        }
+        args = append(args, int64(value), gameID)
+        query += fmt.Sprintf(" AND (%[1]s < $3 OR (%[1]s = $3 AND a.game_id < $4))", key)
+    }
+    args = append(args, limit+1)
+    query += fmt.Sprintf(" ORDER BY %s DESC, a.game_id DESC LIMIT $%d", key, len(args))
+
+    rows, err := db.Query(query, args...)
+    if err != nil {
+        return versus, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var game models.VersusGame
+        err := rows.Scan(&game.GameID, &game.Mode, &game.FinishedAt, &game.Score, &game.Placement, &game.OpponentScore, &game.OpponentPlacement)
+        if err != nil {
+            return versus, err
+        }
+        versus.Matches = append(versus.Matches, game)
+    }
+    if err = rows.Err(); err != nil {
+        return versus, err
+    }
+
+    if len(versus.Matches) > limit {
+        versus.Matches = versus.Matches[:limit]
+        last := versus.Matches[limit-1]
+        versus.NextCursor = encodeCursor(float64(last.FinishedAt.UnixMicro()), last.GameID)
+    }
+    return versus, nil
+}
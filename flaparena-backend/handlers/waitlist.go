@@ -0,0 +1,93 @@
+package handlers
+
+import "log"
+
+// promoteWaiting hands free player slots to the waiting list in order and
+// tells everyone still waiting where they now stand. It reports whether
+// anyone was promoted. A player promoted while a game is running sits out
+// until the next one.
+func (room *Room) promoteWaiting() bool {
+    promoted := false
+    for {
+        room.State.Mutex.Lock()
+        if room.closed || len(room.waiting) == 0 || len(room.State.Players) >= room.Rules.MaxPlayers {
+            room.State.Mutex.Unlock()
+            break
+        }
+        next := room.waiting[0]
+        room.State.Mutex.Unlock()
+
+        if room.promote(next) {
+            promoted = true
+            room.sendWelcome(next)
+        }
+    }
+    room.notifyWaiting()
+    return promoted
+}
+
+// promote seats c if it is still first on the waiting list and a slot is
+// still free. A connection that left in the meantime is dropped from the
+// list instead.
+func (room *Room) promote(c *Connection) bool {
+    c.roomMutex.Lock()
+    defer c.roomMutex.Unlock()
+
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
+
+    if len(room.waiting) == 0 || room.waiting[0] != c {
+        return false
+    }
+    if c.room != room || c.role != roleWaiting {
+        room.waiting = room.waiting[1:]
+        return false
+    }
+    if len(room.State.Players) >= room.Rules.MaxPlayers {
+        return false
+    }
+
+    room.waiting = room.waiting[1:]
+    userIDStr := c.userIDString()
+    room.State.Players[userIDStr] = room.newPlayerState(c)
+    room.issueResumeToken(c)
+    c.role = rolePlayer
+    log.Printf("User %d promoted from the waiting list of room %s", c.userID, room.ID)
+    return true
+}
+
+// notifyWaiting tells every connection on the waiting list its position.
+func (room *Room) notifyWaiting() {
+    room.State.Mutex.Lock()
+    waiting := make([]*Connection, len(room.waiting))
+    copy(waiting, room.waiting)
+    room.State.Mutex.Unlock()
+
+    for i, c := range waiting {
+        c.sendMessage("waitingPosition", map[string]interface{}{
+            "roomID":   room.ID,
+            "position": i + 1,
+        })
+    }
+}
+
+// removeWaiting takes a user off the waiting list. Callers must hold State.Mutex.
+func (room *Room) removeWaiting(userID string) {
+    for i, c := range room.waiting {
+        if c.userIDString() == userID {
+            room.waiting = append(room.waiting[:i:i], room.waiting[i+1:]...)
+            return
+        }
+    }
+}
+
+// waitingPosition returns the user's place on the waiting list, counting
+// from one, or zero if they aren't on it. Callers must hold State.Mutex.
+func (room *Room) waitingPosition(userID string) int {
+    for i, c := range room.waiting {
+        if c.userIDString() == userID {
+            return i + 1
+        }
+    }
+    return 0
+}
@@ -4,13 +4,10 @@ import (
 	"log"
 	"net/http"
 	"strconv"
-	"sync"
     "encoding/json"
-    "time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
-	"github.com/mapleleafu/flaparena/flaparena-backend/models"
 	"github.com/mapleleafu/flaparena/flaparena-backend/responses"
 	"github.com/mapleleafu/flaparena/flaparena-backend/utils"
 )
@@ -21,22 +18,20 @@ var upgrader = websocket.Upgrader{
     CheckOrigin:     func(r *http.Request) bool { return true },
 }
 
-var (
-    gameSessions = make(map[string]*models.GameSession)
-    gameSessionsMutex = &sync.Mutex{}
-)
-
-var currentGameState = &models.GameState{
-    Players: make(map[string]*models.PlayerState),
-    Started: false,
-    Mutex:   sync.Mutex{},
-    GameID: "",
-}
-
+// WsHandler connects a player to a room. Private rooms are joined with an
+// ?invite= code, public ones by roomID. Without either the player joins the
+// default lobby room. Adding ?spectate=true joins as a spectator, and
+// ?resume= with the token from the welcome message takes a dropped player
+// back to their seat.
 func WsHandler(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
     tokenStr := vars["token"]
 
+    roomID, hasRoomID := vars["roomID"]
+    if !hasRoomID {
+        roomID = lobbyRoomID
+    }
+
     // Validate the token
     claims, err := ValidateToken(tokenStr)
     if err != nil {
@@ -52,6 +47,26 @@ func WsHandler(w http.ResponseWriter, r *http.Request) {
         return
     }
 
+    var room *Room
+    var exists bool
+    if resumeToken := r.URL.Query().Get("resume"); resumeToken != "" {
+        room, exists = rooms.FindByResumeToken(claims.ID, resumeToken)
+        if !exists {
+            utils.HandleError(w, responses.NotFoundError{Msg: "Session can no longer be resumed."})
+            return
+        }
+    } else if inviteCode := r.URL.Query().Get("invite"); inviteCode != "" {
+        room, exists = rooms.GetByInviteCode(inviteCode)
+    } else {
+        room, exists = rooms.Get(roomID)
+        // Private rooms can't be joined by ID alone
+        exists = exists && !room.Private
+    }
+    if !exists {
+        utils.HandleError(w, responses.NotFoundError{Msg: "Room not found."})
+        return
+    }
+
     conn, err := upgrader.Upgrade(w, r, nil)
     if err != nil {
         log.Println("Upgrade error:", err)
@@ -59,40 +74,14 @@ func WsHandler(w http.ResponseWriter, r *http.Request) {
     }
     defer conn.Close()
 
-    connection := &Connection{send: make(chan []byte, 256), ws: conn, userID: userID, username: claims.Username}
-
-    // Register the connection to the hub for broadcasting and message handling
-    hub.register <- connection
-
-    // Convert userID to string for map index
-    userIDStr := strconv.FormatUint(userID, 10)
-
-    // Update the player's state in currentGameState
-    currentGameState.Mutex.Lock()
-    currentGameState.Players[userIDStr] = &models.PlayerState{
-        UserID:   userIDStr,
-        Username: claims.Username,
-        Connected: true,
-        Ready:    false,
-        Alive:    false,
-        Score:    0,
+    connection := &Connection{send: make(chan []byte, 256), ws: conn, userID: userID, username: claims.Username, rating: loadRating(claims.ID)}
+    spectate := r.URL.Query().Get("spectate") == "true"
+    if !room.join(connection, spectate) {
+        return
     }
-    currentGameState.Mutex.Unlock()
-
-    log.Printf("User %s connected", userIDStr)
-
-    // Broadcast updated game state to all connections
-    broadcastGameState()
 
     // Setup clean up for when the connection is closed
-    defer func() { 
-        hub.unregister <- connection
-        // Remove the player from the game state
-        currentGameState.Mutex.Lock()
-        delete(currentGameState.Players, userIDStr)
-        currentGameState.Mutex.Unlock()
-        broadcastGameState()
-    }()
+    defer connection.disconnect()
 
     go connection.writePump()
     connection.readPump()
@@ -100,21 +89,6 @@ func WsHandler(w http.ResponseWriter, r *http.Request) {
 
 func (c *Connection) readPump() {
     defer func() {
-        // Before unregistering, check if the game has started and mark the player as dead
-        if currentGameState.Started {
-            userIDStr := strconv.FormatUint(c.userID, 10)
-            if player, exists := currentGameState.Players[userIDStr]; exists && player.Alive {
-                deadAction := models.GameAction{
-                    UserID:    userIDStr,
-                    Action:    "dead",
-                    Timestamp: time.Now().UnixMilli(),
-                }
-                handleDeadAction(deadAction)
-            }
-        }
-        
-        // Unregister the connection and close the WebSocket
-        hub.unregister <- c
         c.ws.Close()
         log.Printf("User %d disconnected", c.userID)
     }()
@@ -125,7 +99,13 @@ func (c *Connection) readPump() {
             log.Printf("Error reading message from userID %d: %v", c.userID, err)
             break
         }
-        processMessage(c, message)
+
+        // The connection has no room while it waits in the matchmaking queue
+        if room := c.currentRoom(); room != nil {
+            room.processMessage(c, message)
+        } else {
+            handleQueuedMessage(c, message)
+        }
     }
 }
 
@@ -142,12 +122,12 @@ func (c *Connection) writePump() {
     }
 }
 
-func broadcastGameState() {
-    currentGameState.Mutex.Lock()
-    defer currentGameState.Mutex.Unlock()
+func (room *Room) broadcastGameState() {
+    room.State.Mutex.Lock()
+    defer room.State.Mutex.Unlock()
 
     gameState := make([]map[string]interface{}, 0)
-    for _, player := range currentGameState.Players {
+    for _, player := range room.State.Players {
         gameState = append(gameState, map[string]interface{}{
             "userID":   player.UserID,
             "username": player.Username,
@@ -155,12 +135,33 @@ func broadcastGameState() {
             "ready":    player.Ready,
             "alive":    player.Alive,
             "score":    player.Score,
+            "bot":      player.Bot,
+            "rating":   player.Rating,
+        })
+    }
+
+    spectators := make([]map[string]interface{}, 0)
+    for _, spectator := range room.State.Spectators {
+        spectators = append(spectators, map[string]interface{}{
+            "userID":   spectator.UserID,
+            "username": spectator.Username,
+        })
+    }
+
+    waiting := make([]map[string]interface{}, 0, len(room.waiting))
+    for i, c := range room.waiting {
+        waiting = append(waiting, map[string]interface{}{
+            "userID":   c.userIDString(),
+            "username": c.username,
+            "position": i + 1,
         })
     }
 
     message, _ := json.Marshal(map[string]interface{}{
         "type": "gameState",
         "data": gameState,
+        "spectators": spectators,
+        "waiting": waiting,
     })
-    hub.broadcast <- message
+    room.hub.Broadcast(message)
 }
\ No newline at end of file
@@ -1,15 +1,46 @@
 package handlers
 
 import (
+    "encoding/json"
+    "log"
+    "strconv"
+    "sync"
+
     "github.com/gorilla/websocket"
 )
 
+// connectionRole is what a connection is allowed to do in its room.
+type connectionRole string
+
+const (
+    rolePlayer    connectionRole = "player"
+    roleSpectator connectionRole = "spectator"
+    // Waiting for a player slot in a full room. Watches like a spectator
+    // until promoted.
+    roleWaiting connectionRole = "waiting"
+)
+
 // Connection represents a WebSocket connection and the user it belongs to.
 type Connection struct {
     ws       *websocket.Conn
     send     chan []byte
     userID   uint64
     username string
+    bot      bool // Driven by a server-side bot instead of a socket
+
+    // Guards rating, which changes after every ranked game.
+    ratingMutex sync.Mutex
+    rating      float64
+
+    // Guards room, role and left while the connection moves between rooms.
+    roomMutex sync.Mutex
+    room      *Room
+    role      connectionRole
+    left      bool
+
+    // Guards send so nothing is written to it after it has been closed.
+    sendMutex sync.Mutex
+    closed    bool
 }
 
 // Hub maintains the set of active connections and broadcasts messages to the connections.
@@ -24,14 +55,20 @@ type Hub struct {
 
     unregister chan *Connection
 
+    // Closed to shut the hub down once its room is gone.
+    stop chan struct{}
+
     // mutex sync.Mutex // Ensure thread safety
 }
 
-var hub = &Hub{
-    broadcast:   make(chan []byte),
-    register:    make(chan *Connection),
-    unregister:  make(chan *Connection),
-    connections: make(map[*Connection]bool),
+func newHub() *Hub {
+    return &Hub{
+        broadcast:   make(chan []byte),
+        register:    make(chan *Connection),
+        unregister:  make(chan *Connection),
+        stop:        make(chan struct{}),
+        connections: make(map[*Connection]bool),
+    }
 }
 
 func (h *Hub) run() {
@@ -40,23 +77,151 @@ func (h *Hub) run() {
         case connection := <-h.register:
             h.connections[connection] = true
         case connection := <-h.unregister:
-            if _, ok := h.connections[connection]; ok {
-                delete(h.connections, connection)
-                close(connection.send)
-            }
+            // The connection may be moving to another room, so its send
+            // channel stays open until the connection itself is closed.
+            delete(h.connections, connection)
         case message := <-h.broadcast:
             for connection := range h.connections {
-                select {
-                case connection.send <- message:
-                default:
-                    close(connection.send)
+                if !connection.write(message) {
+                    connection.close()
                     delete(h.connections, connection)
                 }
             }
+        case <-h.stop:
+            return
         }
     }
 }
 
-func init() {
-    go hub.run()
+// The helpers below hand work to the hub's goroutine, or drop it if the hub
+// has already been stopped so callers never block on a closed room.
+
+func (h *Hub) Register(connection *Connection) {
+    select {
+    case h.register <- connection:
+    case <-h.stop:
+    }
+}
+
+func (h *Hub) Unregister(connection *Connection) {
+    select {
+    case h.unregister <- connection:
+    case <-h.stop:
+    }
+}
+
+func (h *Hub) Broadcast(message []byte) {
+    select {
+    case h.broadcast <- message:
+    case <-h.stop:
+    }
+}
+
+// encodeMessage wraps data in the {type, data} envelope every WebSocket message uses.
+func encodeMessage(messageType string, data interface{}) ([]byte, error) {
+    return json.Marshal(struct {
+        Type string      `json:"type"`
+        Data interface{} `json:"data"`
+    }{
+        Type: messageType,
+        Data: data,
+    })
+}
+
+// write queues a message without blocking. It reports false if the
+// connection is closed or too far behind to take more messages.
+func (c *Connection) write(message []byte) bool {
+    c.sendMutex.Lock()
+    defer c.sendMutex.Unlock()
+
+    if c.closed {
+        return false
+    }
+    select {
+    case c.send <- message:
+        return true
+    default:
+        return false
+    }
+}
+
+// close stops the connection's writePump. It is safe to call more than once.
+func (c *Connection) close() {
+    c.sendMutex.Lock()
+    defer c.sendMutex.Unlock()
+
+    if !c.closed {
+        c.closed = true
+        close(c.send)
+    }
+}
+
+// sendMessage sends a message to this connection only.
+func (c *Connection) sendMessage(messageType string, data interface{}) {
+    message, err := encodeMessage(messageType, data)
+    if err != nil {
+        log.Printf("Error marshalling message: %v", err)
+        return
+    }
+    c.write(message)
+}
+
+func (c *Connection) currentRoom() *Room {
+    c.roomMutex.Lock()
+    defer c.roomMutex.Unlock()
+
+    return c.room
+}
+
+func (c *Connection) isPlayer() bool {
+    c.roomMutex.Lock()
+    defer c.roomMutex.Unlock()
+
+    return c.role == rolePlayer
+}
+
+// leaveRoom takes the connection out of the room it is in, if any. See
+// Room.removeConnection for keepSeat.
+func (c *Connection) leaveRoom(keepSeat bool) {
+    c.roomMutex.Lock()
+    defer c.roomMutex.Unlock()
+
+    if c.room != nil {
+        room := c.room
+        c.room = nil
+        room.removeConnection(c, keepSeat)
+    }
+}
+
+// disconnect cleans up after the socket is gone: the connection leaves its
+// room or the matchmaking queue and can't be moved anywhere else. Players
+// keep their seat for the reconnect grace period.
+func (c *Connection) disconnect() {
+    c.roomMutex.Lock()
+    c.left = true
+    c.roomMutex.Unlock()
+
+    c.leaveRoom(true)
+    matchmaker.Remove(c)
+    c.close()
+}
+
+// userIDString returns the user ID in the form game state maps are keyed by.
+func (c *Connection) userIDString() string {
+    return strconv.FormatUint(c.userID, 10)
+}
+
+// currentRating returns the rating the connection's user is matched with.
+func (c *Connection) currentRating() float64 {
+    c.ratingMutex.Lock()
+    defer c.ratingMutex.Unlock()
+
+    return c.rating
+}
+
+func (c *Connection) setRating(rating float64) {
+    c.ratingMutex.Lock()
+    defer c.ratingMutex.Unlock()
+
+    c.rating = rating
 }
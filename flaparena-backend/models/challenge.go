@@ -0,0 +1,40 @@
+package models
+
+import (
+    "time"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
+    "github.com/mapleleafu/flaparena/flaparena-backend/simulation"
+)
+
+// DailyChallenge is the course everyone flies on a given day, and how the
+// caller has done on it so far.
+type DailyChallenge struct {
+    Day          string                  `json:"day"`
+    Mode         gamemode.Info           `json:"mode"`
+    Rules        RoomRules               `json:"rules"`
+    Course       simulation.CourseParams `json:"course"`
+    Attempts     int                     `json:"attempts"` // Allowed per player
+    AttemptsUsed int                     `json:"attempts_used"` // Claimed so far, played or not
+    BestScore    *int                    `json:"best_score"` // nil until an attempt is finished
+    NextRollover time.Time               `json:"next_rollover"`
+}
+
+// ChallengeAttempt is a room opened for a single daily challenge attempt.
+// It is played by joining /ws/{token}?invite={invite_code}.
+type ChallengeAttempt struct {
+    Day          string `json:"day"`
+    Attempt      int    `json:"attempt"`
+    RoomID       string `json:"room_id"`
+    InviteCode   string `json:"invite_code"`
+    AttemptsLeft int    `json:"attempts_left"`
+}
+
+// ChallengeLeaderboard ranks players by their best finished attempt of a
+// day's challenge. Me is the caller's own entry, or nil if they have none.
+type ChallengeLeaderboard struct {
+    Day        string             `json:"day"`
+    Entries    []LeaderboardEntry `json:"entries"`
+    NextCursor string             `json:"next_cursor,omitempty"`
+    Me         *LeaderboardEntry  `json:"me"`
+}
@@ -7,4 +7,19 @@ type Game struct {
     CreatedAt time.Time `json:"created_at"`
     FinishedAt time.Time `json:"finished_at"`
     UserIDs   []string  `json:"user_ids"`
+    Mode      string    `json:"mode"`
+    BotIDs    []string  `json:"bot_ids"`
+    Rules     RoomRules `json:"rules"`
+    Results   []GameResult `json:"results"` // Best placed first
+
+    // How the player whose history this is did, 0 and false if unknown
+    Score     int       `json:"score"`
+    Placement int       `json:"placement"`
+    Won       bool      `json:"won"`
+}
+
+// GamePage is one page of a player's game history.
+type GamePage struct {
+    Games      []Game `json:"games"`
+    NextCursor string `json:"next_cursor,omitempty"`
 }
@@ -1,14 +1,22 @@
 package models
 
+import (
+    "github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
+    "github.com/mapleleafu/flaparena/flaparena-backend/simulation"
+)
+
 type GameActionMessage struct {
     Action    string `json:"action"`
     Timestamp int64  `json:"timestamp"`
+    Target    string `json:"target,omitempty"`     // User the action is aimed at, e.g. the bot to remove
+    Difficulty string `json:"difficulty,omitempty"` // Skill of a bot being added
 }
 
 type GameAction struct {
     UserID  string `bson:"userId"`
     Action    string `bson:"action"`
     Timestamp int64  `bson:"timestamp"`
+    Target    string `bson:"target,omitempty"` // Player a server action was taken against
 }
 
 type GameEvent struct {
@@ -19,5 +27,9 @@ type GameEvent struct {
 // GameSession represents all actions taken in a single game session.
 type GameSession struct {
     ID      string       `bson:"_id,omitempty"`
+    Mode    gamemode.Info `bson:"mode"`
+    Rules   RoomRules `bson:"rules"`
+    Course  simulation.CourseParams `bson:"course"`
+    Bots    []string `bson:"bots,omitempty"` // User IDs of the players that were bots
     Actions []GameAction `bson:"actions"`
 }
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// GameResult is how a single player did in a finished game.
+type GameResult struct {
+    GameID       string    `json:"game_id"`
+    UserID       string    `json:"user_id"`
+    Mode         string    `json:"mode"`
+    Score        int       `json:"score"` // Best round score of the match
+    Placement    int       `json:"placement"` // 1 for the winners, tied players share a placement
+    TimeAlive    int64     `json:"time_alive_ms"` // Across every round of the match
+    Flaps        int       `json:"flaps"`
+    Won          bool      `json:"won"`
+    Bot          bool      `json:"bot"`
+    Disconnected bool      `json:"disconnected"` // Left or lost connection before the end
+    FinishedAt   time.Time `json:"finished_at"`
+}
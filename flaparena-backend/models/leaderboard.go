@@ -0,0 +1,36 @@
+package models
+
+// Leaderboard metrics.
+const (
+    MetricScore  = "score"  // Best score in a single game
+    MetricWins   = "wins"   // Games won
+    MetricRating = "rating" // Current rating
+)
+
+// Leaderboard periods.
+const (
+    PeriodAll    = "all"
+    PeriodSeason = "season"
+    PeriodWeek   = "week"
+    PeriodDay    = "day"
+)
+
+type LeaderboardEntry struct {
+    Rank     int     `json:"rank"`
+    UserID   string  `json:"user_id"`
+    Username string  `json:"username"`
+    Value    float64 `json:"value"`
+    Games    int     `json:"games"`
+}
+
+// Leaderboard is one page of rankings. Me is the caller's own entry, or
+// nil if they aren't ranked.
+type Leaderboard struct {
+    Metric     string             `json:"metric"`
+    Period     string             `json:"period"`
+    Season     string             `json:"season,omitempty"`
+    Mode       string             `json:"mode,omitempty"`
+    Entries    []LeaderboardEntry `json:"entries"`
+    NextCursor string             `json:"next_cursor,omitempty"`
+    Me         *LeaderboardEntry  `json:"me"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+    "time"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/rating"
+)
+
+// Profile is a user as other players see them.
+type Profile struct {
+    ID         uint          `json:"id"`
+    Username   string        `json:"username"`
+    Rating     rating.Rating `json:"rating"`
+    RatedGames int           `json:"rated_games"`
+    Stats      PlayerStats   `json:"stats"`
+    Achievements []UnlockedAchievement `json:"achievements"` // Latest first
+}
+
+// PlayerStats sums up every game a user has finished.
+type PlayerStats struct {
+    GamesPlayed      int     `json:"games_played"`
+    Wins             int     `json:"wins"`
+    BestScore        int     `json:"best_score"`
+    AverageScore     float64 `json:"average_score"`
+    TotalFlaps       int64   `json:"total_flaps"`
+    AverageTimeAlive float64 `json:"average_time_alive_ms"`
+    CurrentStreak    int     `json:"current_win_streak"`
+    LongestStreak    int     `json:"longest_win_streak"`
+    RecentForm       string  `json:"recent_form"` // W or L per game, newest first
+}
+
+// UnlockedAchievement is an achievement a user has earned.
+type UnlockedAchievement struct {
+    ID          string    `json:"id"`
+    Name        string    `json:"name"`
+    Description string    `json:"description"`
+    GameID      string    `json:"game_id"`
+    UnlockedAt  time.Time `json:"unlocked_at"`
+}
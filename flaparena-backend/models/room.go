@@ -0,0 +1,27 @@
+package models
+
+import "github.com/mapleleafu/flaparena/flaparena-backend/gamemode"
+
+// RoomInfo is the public summary of a room returned by the rooms API.
+type RoomInfo struct {
+    ID      string `json:"id"`
+    Players int    `json:"players"`
+    Spectators []string `json:"spectators"`
+    Waiting int         `json:"waiting"` // Connections queued for a player slot
+    Started bool   `json:"started"`
+    Private bool   `json:"private"`
+    Mode    gamemode.Info `json:"mode"`
+    Rules   RoomRules `json:"rules"`
+    OwnerID string `json:"ownerID,omitempty"`
+    // Only filled in for the owner when a private room is created
+    InviteCode string `json:"inviteCode,omitempty"`
+}
+
+// CreateRoomRequest is the optional body when creating a room. Rules not
+// given keep their defaults.
+type CreateRoomRequest struct {
+    Mode      string    `json:"mode"`
+    Rounds    int       `json:"rounds"`
+    TimeLimit int       `json:"timeLimit"` // seconds
+    Rules     RoomRules `json:"rules"`
+}
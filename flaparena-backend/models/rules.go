@@ -0,0 +1,115 @@
+package models
+
+import (
+    "database/sql/driver"
+    "encoding/json"
+    "fmt"
+
+    "github.com/mapleleafu/flaparena/flaparena-backend/simulation"
+)
+
+// RoomRules are the physics, course and lobby settings a room plays with.
+// They are fixed when the room is created and stored with every game so
+// results are only compared between games with the same rules.
+type RoomRules struct {
+    Gravity      float64 `json:"gravity" bson:"gravity"`
+    JumpStrength float64 `json:"jumpStrength" bson:"jumpStrength"`
+    PipeGap      float64 `json:"pipeGap" bson:"pipeGap"`
+    PipeSpacing  float64 `json:"pipeSpacing" bson:"pipeSpacing"`
+    SpeedRamp    float64 `json:"speedRamp" bson:"speedRamp"` // pipe speed gained per second
+    MinPlayers   int     `json:"minPlayers" bson:"minPlayers"`
+    MaxPlayers   int     `json:"maxPlayers" bson:"maxPlayers"`
+    Countdown    int     `json:"countdown" bson:"countdown"` // seconds
+
+    // Timeouts in seconds; zero turns one off.
+    IdleTimeout  int `json:"idleTimeout" bson:"idleTimeout"`   // kill a bird whose player sent nothing
+    MaxDuration  int `json:"maxDuration" bson:"maxDuration"`   // end the game outright
+    LobbyTimeout int `json:"lobbyTimeout" bson:"lobbyTimeout"` // kick a player who never readies up
+}
+
+// DefaultRoomRules returns the rules every game used before they were configurable.
+func DefaultRoomRules() RoomRules {
+    physics := simulation.DefaultPhysics()
+    course := simulation.DefaultCourseParams(0)
+    return RoomRules{
+        Gravity:      physics.Gravity,
+        JumpStrength: physics.JumpStrength,
+        PipeGap:      course.GapSize,
+        PipeSpacing:  course.Spacing,
+        SpeedRamp:    0,
+        MinPlayers:   2,
+        MaxPlayers:   20,
+        Countdown:    5,
+        IdleTimeout:  30,
+        MaxDuration:  15 * 60,
+        LobbyTimeout: 5 * 60,
+    }
+}
+
+// Validate makes sure the rules describe a playable game.
+func (r RoomRules) Validate() error {
+    switch {
+    case r.Gravity <= 0 || r.Gravity > 2:
+        return fmt.Errorf("gravity must be between 0 and 2")
+    case r.JumpStrength >= 0 || r.JumpStrength < -30:
+        return fmt.Errorf("jumpStrength must be between -30 and 0")
+    case r.PipeGap < simulation.BirdHeight+25 || r.PipeGap > simulation.WorldHeight-100:
+        return fmt.Errorf("pipeGap must be between %.0f and %.0f", simulation.BirdHeight+25, simulation.WorldHeight-100)
+    case r.PipeSpacing < simulation.PipeWidth+simulation.BirdHitbox || r.PipeSpacing > simulation.WorldWidth:
+        return fmt.Errorf("pipeSpacing must be between %.0f and %.0f", simulation.PipeWidth+simulation.BirdHitbox, simulation.WorldWidth)
+    case r.SpeedRamp < 0 || r.SpeedRamp > 1:
+        return fmt.Errorf("speedRamp must be between 0 and 1")
+    case r.MinPlayers < 1 || r.MinPlayers > r.MaxPlayers:
+        return fmt.Errorf("minPlayers must be at least 1 and no more than maxPlayers")
+    case r.MaxPlayers > 20:
+        return fmt.Errorf("maxPlayers can be at most 20")
+    case r.Countdown < 0 || r.Countdown > 30:
+        return fmt.Errorf("countdown must be between 0 and 30 seconds")
+    case r.IdleTimeout < 0 || r.IdleTimeout > 300:
+        return fmt.Errorf("idleTimeout must be between 0 and 300 seconds")
+    case r.MaxDuration < 0 || r.MaxDuration > 2*60*60:
+        return fmt.Errorf("maxDuration must be between 0 and 7200 seconds")
+    case r.LobbyTimeout < 0 || r.LobbyTimeout > 60*60:
+        return fmt.Errorf("lobbyTimeout must be between 0 and 3600 seconds")
+    }
+    return nil
+}
+
+// Physics converts the rules for the simulation.
+func (r RoomRules) Physics() simulation.Physics {
+    physics := simulation.DefaultPhysics()
+    physics.Gravity = r.Gravity
+    physics.JumpStrength = r.JumpStrength
+    physics.SpeedRamp = r.SpeedRamp / simulation.TicksPerSecond
+    return physics
+}
+
+// CourseParams lays out a course with the rules' pipes for seed.
+func (r RoomRules) CourseParams(seed uint32) simulation.CourseParams {
+    course := simulation.DefaultCourseParams(seed)
+    course.GapSize = r.PipeGap
+    course.Spacing = r.PipeSpacing
+    course.MaxGapTop = simulation.WorldHeight - r.PipeGap
+    return course
+}
+
+// Value stores the rules in a JSONB column.
+func (r RoomRules) Value() (driver.Value, error) {
+    return json.Marshal(r)
+}
+
+// Scan reads the rules from a JSONB column. Games recorded before rules
+// existed have none and scan as the zero value.
+func (r *RoomRules) Scan(src interface{}) error {
+    switch value := src.(type) {
+    case nil:
+        *r = RoomRules{}
+        return nil
+    case []byte:
+        return json.Unmarshal(value, r)
+    case string:
+        return json.Unmarshal([]byte(value), r)
+    default:
+        return fmt.Errorf("cannot scan %T into RoomRules", src)
+    }
+}
@@ -0,0 +1,15 @@
+package models
+
+// Snapshot is the compact view of a running game broadcast every server tick.
+type Snapshot struct {
+    Tick    int64            `json:"tick"`
+    Players []PlayerSnapshot `json:"players"`
+}
+
+type PlayerSnapshot struct {
+    UserID   string  `json:"id"`
+    Y        float64 `json:"y"`
+    Velocity float64 `json:"vy"`
+    Alive    bool    `json:"alive"`
+    Score    int     `json:"score"`
+}
@@ -2,6 +2,9 @@ package models
 
 import (
 	"sync"
+	"time"
+
+	"github.com/mapleleafu/flaparena/flaparena-backend/simulation"
 )
 
 type PlayerState struct {
@@ -11,11 +14,30 @@ type PlayerState struct {
     Ready bool
     Alive bool
     Score int
+    DiedAt int64 // Simulation tick the bird died at
+    RoundWins int
+    BestScore int // Best round score of the current match
+    Flaps int // Flaps in the current match
+    TicksAlive int64 // Simulation ticks survived in the current match's finished rounds
+    Bot bool // Controlled by the server rather than a person
+    Rating float64 // For display in the lobby
+    LastInput time.Time // Last message from the player, for the idle timeouts
+}
+
+// SpectatorState is someone watching a room without playing in it.
+type SpectatorState struct {
+    UserID string
+    Username string
 }
 
 type GameState struct {
     Players map[string]*PlayerState
+    Spectators map[string]*SpectatorState
     Started bool
     Mutex   sync.Mutex
     GameID string
+    StartedAt time.Time // Start of the current round
+    Round int
+    Course simulation.CourseParams
+    World *simulation.World
 }
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Tournament formats.
+const (
+    SingleElimination = "singleElimination"
+    RoundRobin        = "roundRobin"
+)
+
+// Tournament statuses, in the order a tournament goes through them.
+const (
+    TournamentRegistration = "registration"
+    TournamentRunning      = "running"
+    TournamentFinished     = "finished"
+)
+
+// Tournament match statuses.
+const (
+    MatchPending  = "pending"  // Waiting for its players
+    MatchPlaying  = "playing"  // Has a room open
+    MatchFinished = "finished"
+)
+
+type Tournament struct {
+    ID         string     `json:"id"`
+    Name       string     `json:"name"`
+    Format     string     `json:"format"`
+    OwnerID    string     `json:"owner_id"`
+    Status     string     `json:"status"`
+    Mode       string     `json:"mode"`
+    Rounds     int        `json:"rounds"`
+    TimeLimit  int        `json:"time_limit"`
+    WinnerID   string     `json:"winner_id,omitempty"`
+    CreatedAt  time.Time  `json:"created_at"`
+    Players    []TournamentPlayer `json:"players"`
+    Matches    []TournamentMatch  `json:"matches"`
+}
+
+type TournamentPlayer struct {
+    UserID   string `json:"user_id"`
+    Username string `json:"username"`
+    Seed     int    `json:"seed"`
+    Wins     int    `json:"wins"`
+}
+
+// TournamentMatch is one pairing in a tournament. Its players join the
+// room through /ws/{token}?invite={invite_code} once it is playing.
+type TournamentMatch struct {
+    ID         string `json:"id"`
+    Round      int    `json:"round"`
+    Position   int    `json:"position"`
+    Player1ID  string `json:"player1_id,omitempty"`
+    Player2ID  string `json:"player2_id,omitempty"`
+    WinnerID   string `json:"winner_id,omitempty"`
+    Status     string `json:"status"`
+    RoomID     string `json:"room_id,omitempty"`
+    InviteCode string `json:"invite_code,omitempty"`
+    GameID     string `json:"game_id,omitempty"`
+}
+
+// CreateTournamentRequest is the body for creating a tournament. Mode,
+// Rounds and TimeLimit work as in CreateRoomRequest.
+type CreateTournamentRequest struct {
+    Name      string `json:"name"`
+    Format    string `json:"format"`
+    Mode      string `json:"mode"`
+    Rounds    int    `json:"rounds"`
+    TimeLimit int    `json:"timeLimit"` // seconds
+}
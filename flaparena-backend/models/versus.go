@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Versus is how two users have done against each other in the games they
+// played together. Wins, losses and score differences are from UserID's
+// side, and a win means placing ahead of the other user.
+type Versus struct {
+    UserID            string       `json:"user_id"`
+    Username          string       `json:"username"`
+    OpponentID        string       `json:"opponent_id"`
+    OpponentUsername  string       `json:"opponent_username"`
+    Games             int          `json:"games"`
+    Wins              int          `json:"wins"`
+    Losses            int          `json:"losses"`
+    Draws             int          `json:"draws"`
+    AverageScoreDiff  float64      `json:"average_score_diff"`
+    Matches           []VersusGame `json:"matches"` // One page of them, latest first
+    NextCursor        string       `json:"next_cursor,omitempty"`
+}
+
+// VersusGame is a single game two users played together.
+type VersusGame struct {
+    GameID            string    `json:"game_id"`
+    Mode              string    `json:"mode"`
+    FinishedAt        time.Time `json:"finished_at"`
+    Score             int       `json:"score"`
+    Placement         int       `json:"placement"`
+    OpponentScore     int       `json:"opponent_score"`
+    OpponentPlacement int       `json:"opponent_placement"`
+}
@@ -0,0 +1,146 @@
+// Package rating rates players with Glicko-2, generalized to games of any
+// number of players by scoring every pair of players by their placement.
+package rating
+
+import "math"
+
+// Starting values for players who haven't been rated yet.
+const (
+    DefaultRating     = 1500.0
+    DefaultDeviation  = 350.0
+    DefaultVolatility = 0.06
+)
+
+const (
+    scale   = 173.7178 // Converts between the Glicko and Glicko-2 scales
+    tau     = 0.5      // How much volatility may change in a single game
+    epsilon = 0.000001 // Convergence tolerance of the volatility iteration
+)
+
+// Rating is a player's skill estimate on the Glicko scale.
+type Rating struct {
+    Rating     float64 `json:"rating"`
+    Deviation  float64 `json:"deviation"`
+    Volatility float64 `json:"volatility"`
+}
+
+// Default is the rating of a new player.
+func Default() Rating {
+    return Rating{Rating: DefaultRating, Deviation: DefaultDeviation, Volatility: DefaultVolatility}
+}
+
+// Standing is a player's rating going into a game and where they placed,
+// counted from 1. Players sharing a placement drew with each other.
+type Standing struct {
+    Rating    Rating
+    Placement int
+}
+
+// Update rates a finished game and returns everyone's new rating, in the
+// order of standings. Each player is scored against every other one as a
+// win, draw or loss, with each pairing weighted so that a game counts as
+// much as a single two-player game however many played in it.
+func Update(standings []Standing) []Rating {
+    updated := make([]Rating, len(standings))
+    if len(standings) < 2 {
+        for i, standing := range standings {
+            updated[i] = standing.Rating
+        }
+        return updated
+    }
+
+    weight := 1 / float64(len(standings)-1)
+    for i, player := range standings {
+        var outcomes []outcome
+        for j, opponent := range standings {
+            if i == j {
+                continue
+            }
+            score := 0.5
+            if player.Placement < opponent.Placement {
+                score = 1
+            } else if player.Placement > opponent.Placement {
+                score = 0
+            }
+            outcomes = append(outcomes, outcome{opponent: opponent.Rating, score: score, weight: weight})
+        }
+        updated[i] = player.Rating.update(outcomes)
+    }
+    return updated
+}
+
+type outcome struct {
+    opponent Rating
+    score    float64 // 1 for a win, 0.5 for a draw, 0 for a loss
+    weight   float64
+}
+
+// update applies Glickman's Glicko-2 steps for a single rating period.
+func (r Rating) update(outcomes []outcome) Rating {
+    mu := (r.Rating - DefaultRating) / scale
+    phi := r.Deviation / scale
+
+    var inverseV, improvement float64
+    for _, o := range outcomes {
+        muJ := (o.opponent.Rating - DefaultRating) / scale
+        gJ := g(o.opponent.Deviation / scale)
+        expected := 1 / (1 + math.Exp(-gJ*(mu-muJ)))
+        inverseV += o.weight * gJ * gJ * expected * (1 - expected)
+        improvement += o.weight * gJ * (o.score - expected)
+    }
+    if inverseV == 0 {
+        return r
+    }
+    v := 1 / inverseV
+    delta := v * improvement
+
+    volatility := newVolatility(phi, r.Volatility, v, delta)
+    phiStar := math.Sqrt(phi*phi + volatility*volatility)
+    newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+    newMu := mu + newPhi*newPhi*improvement
+
+    return Rating{
+        Rating:     newMu*scale + DefaultRating,
+        Deviation:  math.Min(newPhi*scale, DefaultDeviation),
+        Volatility: volatility,
+    }
+}
+
+func g(phi float64) float64 {
+    return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+// newVolatility finds the new volatility with the Illinois algorithm.
+func newVolatility(phi, sigma, v, delta float64) float64 {
+    a := math.Log(sigma * sigma)
+    f := func(x float64) float64 {
+        ex := math.Exp(x)
+        d := phi*phi + v + ex
+        return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(tau*tau)
+    }
+
+    A := a
+    var B float64
+    if delta*delta > phi*phi+v {
+        B = math.Log(delta*delta - phi*phi - v)
+    } else {
+        k := 1.0
+        for f(a-k*tau) < 0 {
+            k++
+        }
+        B = a - k*tau
+    }
+
+    fA, fB := f(A), f(B)
+    for math.Abs(B-A) > epsilon {
+        C := A + (A-B)*fA/(fB-fA)
+        fC := f(C)
+        if fC*fB <= 0 {
+            A, fA = B, fB
+        } else {
+            fA /= 2
+        }
+        B, fB = C, fC
+    }
+    return math.Exp(A / 2)
+}
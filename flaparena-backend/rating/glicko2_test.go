@@ -0,0 +1,122 @@
+package rating
+
+import (
+    "math"
+    "testing"
+)
+
+func near(got, want, tolerance float64) bool {
+    return math.Abs(got-want) <= tolerance
+}
+
+// TestGlickmanExample runs the worked example from Glickman's "Example of
+// the Glicko-2 system", where every game counts in full.
+func TestGlickmanExample(t *testing.T) {
+    player := Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+    got := player.update([]outcome{
+        {opponent: Rating{Rating: 1400, Deviation: 30}, score: 1, weight: 1},
+        {opponent: Rating{Rating: 1550, Deviation: 100}, score: 0, weight: 1},
+        {opponent: Rating{Rating: 1700, Deviation: 300}, score: 0, weight: 1},
+    })
+
+    if !near(got.Rating, 1464.06, 0.01) || !near(got.Deviation, 151.52, 0.01) || !near(got.Volatility, 0.05999, 0.00001) {
+        t.Fatalf("got %+v, want 1464.06, 151.52 and 0.05999", got)
+    }
+}
+
+func TestTwoPlayerGame(t *testing.T) {
+    winner := Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+    loser := Rating{Rating: 1400, Deviation: 30, Volatility: 0.06}
+    got := Update([]Standing{{Rating: winner, Placement: 1}, {Rating: loser, Placement: 2}})
+
+    // With a single opponent the pairing counts in full, as in plain Glicko-2
+    want := []Rating{
+        winner.update([]outcome{{opponent: loser, score: 1, weight: 1}}),
+        loser.update([]outcome{{opponent: winner, score: 0, weight: 1}}),
+    }
+    if got[0] != want[0] || got[1] != want[1] {
+        t.Fatalf("got %+v, want %+v", got, want)
+    }
+    if got[0].Rating <= winner.Rating || got[1].Rating >= loser.Rating {
+        t.Fatalf("ratings moved the wrong way: %+v", got)
+    }
+}
+
+func TestDrawBetweenEqualPlayers(t *testing.T) {
+    got := Update([]Standing{{Rating: Default(), Placement: 1}, {Rating: Default(), Placement: 1}})
+
+    for i, rating := range got {
+        if !near(rating.Rating, DefaultRating, 1e-9) {
+            t.Fatalf("player %d moved to %v after a draw", i, rating.Rating)
+        }
+        if rating.Deviation >= DefaultDeviation {
+            t.Fatalf("player %d kept deviation %v after playing", i, rating.Deviation)
+        }
+    }
+    if got[0] != got[1] {
+        t.Fatalf("equal players drew but were rated %+v and %+v", got[0], got[1])
+    }
+}
+
+func TestMultiplayerOrder(t *testing.T) {
+    // Standings are given out of placement order on purpose
+    standings := []Standing{
+        {Rating: Default(), Placement: 3},
+        {Rating: Default(), Placement: 1},
+        {Rating: Default(), Placement: 4},
+        {Rating: Default(), Placement: 1},
+    }
+    got := Update(standings)
+
+    if got[1] != got[3] {
+        t.Fatalf("players sharing first place were rated %+v and %+v", got[1], got[3])
+    }
+    if !(got[1].Rating > got[0].Rating && got[0].Rating > got[2].Rating) {
+        t.Fatalf("ratings %v, %v and %v do not follow the placements", got[1].Rating, got[0].Rating, got[2].Rating)
+    }
+    if got[1].Rating <= DefaultRating || got[2].Rating >= DefaultRating {
+        t.Fatalf("first place at %v and last place at %v", got[1].Rating, got[2].Rating)
+    }
+
+    // Equal players gain and lose symmetrically, so the total is unchanged
+    var total float64
+    for _, rating := range got {
+        total += rating.Rating
+    }
+    if !near(total, 4*DefaultRating, 1e-3) {
+        t.Fatalf("ratings add up to %v", total)
+    }
+}
+
+func TestGameWeighsLikeOneGame(t *testing.T) {
+    // Beating everyone counts the same however many played, and sharing
+    // last place is better than losing outright
+    twoPlayers := Update([]Standing{{Rating: Default(), Placement: 1}, {Rating: Default(), Placement: 2}})
+    threePlayers := Update([]Standing{{Rating: Default(), Placement: 1}, {Rating: Default(), Placement: 2}, {Rating: Default(), Placement: 2}})
+
+    if !near(twoPlayers[0].Rating, threePlayers[0].Rating, 1e-6) {
+        t.Fatalf("beating everyone gave %v with one opponent and %v with two", twoPlayers[0].Rating, threePlayers[0].Rating)
+    }
+    if threePlayers[1].Rating <= twoPlayers[1].Rating {
+        t.Fatalf("sharing last place gave %v, losing alone %v", threePlayers[1].Rating, twoPlayers[1].Rating)
+    }
+}
+
+func TestDeviationCap(t *testing.T) {
+    // An opponent nothing is known about barely informs the rating, so the
+    // volatility would otherwise push the deviation past its starting value
+    player := Rating{Rating: 1500, Deviation: DefaultDeviation, Volatility: 0.5}
+    unknown := Rating{Rating: 1500, Deviation: 3500, Volatility: DefaultVolatility}
+    got := Update([]Standing{{Rating: player, Placement: 1}, {Rating: unknown, Placement: 2}})
+
+    if got[0].Deviation != DefaultDeviation {
+        t.Fatalf("deviation %v, want it capped at %v", got[0].Deviation, DefaultDeviation)
+    }
+}
+
+func TestSinglePlayerKeepsRating(t *testing.T) {
+    player := Rating{Rating: 1620, Deviation: 80, Volatility: 0.06}
+    if got := Update([]Standing{{Rating: player, Placement: 1}}); got[0] != player {
+        t.Fatalf("got %+v, want %+v", got[0], player)
+    }
+}
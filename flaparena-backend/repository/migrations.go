@@ -0,0 +1,114 @@
+package repository
+
+import (
+    "log"
+)
+
+// migrations bring an existing database up to date with the columns and
+// tables the server expects. Each statement must be safe to run again.
+var migrations = []string{
+    `ALTER TABLE games ADD COLUMN IF NOT EXISTS mode TEXT NOT NULL DEFAULT 'lastBirdStanding'`,
+    `ALTER TABLE games ADD COLUMN IF NOT EXISTS rules JSONB`,
+    `ALTER TABLE games ADD COLUMN IF NOT EXISTS bot_ids TEXT[] NOT NULL DEFAULT '{}'`,
+    `CREATE TABLE IF NOT EXISTS tournaments (
+        id TEXT PRIMARY KEY,
+        name TEXT NOT NULL,
+        format TEXT NOT NULL,
+        owner_id TEXT NOT NULL,
+        status TEXT NOT NULL DEFAULT 'registration',
+        mode TEXT NOT NULL,
+        rounds INTEGER NOT NULL DEFAULT 0,
+        time_limit INTEGER NOT NULL DEFAULT 0,
+        winner_id TEXT,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    )`,
+    `CREATE TABLE IF NOT EXISTS tournament_players (
+        tournament_id TEXT NOT NULL REFERENCES tournaments (id) ON DELETE CASCADE,
+        user_id TEXT NOT NULL,
+        seed INTEGER NOT NULL DEFAULT 0,
+        registered_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (tournament_id, user_id)
+    )`,
+    `CREATE TABLE IF NOT EXISTS tournament_matches (
+        id TEXT PRIMARY KEY,
+        tournament_id TEXT NOT NULL REFERENCES tournaments (id) ON DELETE CASCADE,
+        round INTEGER NOT NULL,
+        position INTEGER NOT NULL,
+        player1_id TEXT,
+        player2_id TEXT,
+        winner_id TEXT,
+        status TEXT NOT NULL DEFAULT 'pending',
+        room_id TEXT,
+        invite_code TEXT,
+        game_id TEXT,
+        UNIQUE (tournament_id, round, position)
+    )`,
+    `CREATE TABLE IF NOT EXISTS game_results (
+        game_id TEXT NOT NULL,
+        user_id TEXT NOT NULL,
+        mode TEXT NOT NULL,
+        score INTEGER NOT NULL DEFAULT 0,
+        won BOOLEAN NOT NULL DEFAULT FALSE,
+        bot BOOLEAN NOT NULL DEFAULT FALSE,
+        finished_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (game_id, user_id)
+    )`,
+    `CREATE INDEX IF NOT EXISTS game_results_finished_at ON game_results (finished_at)`,
+    `CREATE TABLE IF NOT EXISTS ratings (
+        user_id TEXT PRIMARY KEY,
+        rating DOUBLE PRECISION NOT NULL DEFAULT 1500,
+        games INTEGER NOT NULL DEFAULT 0,
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    )`,
+    `ALTER TABLE game_results ADD COLUMN IF NOT EXISTS placement INTEGER NOT NULL DEFAULT 0`,
+    `ALTER TABLE game_results ADD COLUMN IF NOT EXISTS time_alive_ms BIGINT NOT NULL DEFAULT 0`,
+    `ALTER TABLE game_results ADD COLUMN IF NOT EXISTS flaps INTEGER NOT NULL DEFAULT 0`,
+    `ALTER TABLE game_results ADD COLUMN IF NOT EXISTS disconnected BOOLEAN NOT NULL DEFAULT FALSE`,
+    `CREATE INDEX IF NOT EXISTS game_results_user_id ON game_results (user_id)`,
+    `ALTER TABLE ratings ADD COLUMN IF NOT EXISTS deviation DOUBLE PRECISION NOT NULL DEFAULT 350`,
+    `ALTER TABLE ratings ADD COLUMN IF NOT EXISTS volatility DOUBLE PRECISION NOT NULL DEFAULT 0.06`,
+    `CREATE TABLE IF NOT EXISTS rating_history (
+        game_id TEXT NOT NULL,
+        user_id TEXT NOT NULL,
+        rating_before DOUBLE PRECISION NOT NULL,
+        rating_after DOUBLE PRECISION NOT NULL,
+        deviation DOUBLE PRECISION NOT NULL,
+        volatility DOUBLE PRECISION NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (game_id, user_id)
+    )`,
+    `CREATE INDEX IF NOT EXISTS rating_history_user_id ON rating_history (user_id, created_at)`,
+    `CREATE INDEX IF NOT EXISTS game_results_user_finished_at ON game_results (user_id, finished_at)`,
+    `DROP INDEX IF EXISTS game_results_user_id`,
+    `CREATE TABLE IF NOT EXISTS user_achievements (
+        user_id TEXT NOT NULL,
+        achievement_id TEXT NOT NULL,
+        game_id TEXT NOT NULL,
+        unlocked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (user_id, achievement_id)
+    )`,
+    `CREATE TABLE IF NOT EXISTS challenge_attempts (
+        day TEXT NOT NULL,
+        user_id TEXT NOT NULL,
+        attempt INTEGER NOT NULL,
+        room_id TEXT,
+        invite_code TEXT,
+        game_id TEXT,
+        score INTEGER,
+        created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        started_at TIMESTAMPTZ,
+        finished_at TIMESTAMPTZ,
+        PRIMARY KEY (day, user_id, attempt)
+    )`,
+}
+
+// Migrate applies every migration to PostgreSQLDB in order.
+func Migrate() error {
+    for _, migration := range migrations {
+        if _, err := PostgreSQLDB.Exec(migration); err != nil {
+            return err
+        }
+    }
+    log.Println("Database migrations applied")
+    return nil
+}
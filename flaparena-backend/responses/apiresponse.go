@@ -54,3 +54,15 @@ func (InternalServerError) StatusCode() int {
 	return 500
 }
 
+
+type ForbiddenError struct {
+	Msg string
+}
+
+func (e ForbiddenError) Error() string {
+	return e.Msg
+}
+
+func (ForbiddenError) StatusCode() int {
+	return 403
+}
@@ -0,0 +1,59 @@
+package simulation
+
+// CourseParams fully describe a course. Every player in a game receives the
+// same params, and replays use them to rebuild the pipes.
+type CourseParams struct {
+    Seed      uint32  `json:"seed" bson:"seed"`
+    Spacing   float64 `json:"spacing" bson:"spacing"`
+    GapSize   float64 `json:"gapSize" bson:"gapSize"`
+    MinGapTop float64 `json:"minGapTop" bson:"minGapTop"`
+    MaxGapTop float64 `json:"maxGapTop" bson:"maxGapTop"`
+}
+
+// DefaultCourseParams returns the standard course layout for seed.
+func DefaultCourseParams(seed uint32) CourseParams {
+    return CourseParams{
+        Seed:      seed,
+        Spacing:   450,
+        GapSize:   250,
+        MinGapTop: 50,
+        MaxGapTop: WorldHeight - 250,
+    }
+}
+
+// Pipe is a single pipe pair on the course.
+type Pipe struct {
+    X       float64 // horizontal position at tick 0
+    GapTop  float64
+    GapSize float64
+}
+
+// Course lazily generates the pipes a world is played on. Pipe i always
+// consumes the i-th value of the seeded generator, so the layout only
+// depends on the params.
+type Course struct {
+    Params CourseParams
+    random *Random
+    pipes  []Pipe
+}
+
+func NewCourse(params CourseParams) *Course {
+    return &Course{
+        Params: params,
+        random: NewRandom(params.Seed),
+    }
+}
+
+// Pipe returns the i-th pipe on the course, generating it if needed.
+func (c *Course) Pipe(i int) Pipe {
+    for len(c.pipes) <= i {
+        n := len(c.pipes)
+        gapRange := c.Params.MaxGapTop - c.Params.MinGapTop
+        c.pipes = append(c.pipes, Pipe{
+            X:       WorldWidth + float64(n)*c.Params.Spacing,
+            GapTop:  c.Params.MinGapTop + c.random.Float64()*gapRange,
+            GapSize: c.Params.GapSize,
+        })
+    }
+    return c.pipes[i]
+}
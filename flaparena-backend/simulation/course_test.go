@@ -0,0 +1,44 @@
+package simulation
+
+import "testing"
+
+// The frontend's Course.js is checked against these same values, so a
+// change here has to be made there too.
+
+func TestRandomSequence(t *testing.T) {
+    tests := []struct {
+        seed uint32
+        want []float64
+    }{
+        {123456789, []float64{0.2577907438389957, 0.9707721115555614, 0.7853280142880976, 0.20616457983851433, 0.30307188746519387}},
+        {0, []float64{0.26642920868471265, 0.0003297457005828619, 0.2232720274478197, 0.1462021479383111, 0.46732782293111086}},
+        {0xFFFFFFFF, []float64{0.8964226141106337, 0.189478256739676, 0.7156526781618595, 0.9440599093213677, 0.8452364315744489}},
+    }
+
+    for _, test := range tests {
+        random := NewRandom(test.seed)
+        for i, want := range test.want {
+            if got := random.Float64(); got != want {
+                t.Fatalf("seed %d, value %d: got %v, want %v", test.seed, i, got, want)
+            }
+        }
+    }
+}
+
+func TestCoursePipes(t *testing.T) {
+    want := map[int]Pipe{
+        0:  {X: 1280, GapTop: 158.2721124123782, GapSize: 250},
+        1:  {X: 1730, GapTop: 457.7242868533358, GapSize: 250},
+        2:  {X: 2180, GapTop: 379.837766001001, GapSize: 250},
+        3:  {X: 2630, GapTop: 136.58912353217602, GapSize: 250},
+        10: {X: 5780, GapTop: 203.67838464211673, GapSize: 250},
+    }
+
+    // Asking for a later pipe first must not change the earlier ones
+    course := NewCourse(DefaultCourseParams(123456789))
+    for _, i := range []int{10, 0, 3, 1, 2} {
+        if got := course.Pipe(i); got != want[i] {
+            t.Fatalf("pipe %d: got %+v, want %+v", i, got, want[i])
+        }
+    }
+}
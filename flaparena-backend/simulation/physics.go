@@ -0,0 +1,46 @@
+package simulation
+
+// TicksPerSecond is the rate the simulation steps at. All per-tick values
+// below match the 60 FPS loop the frontend draws with.
+const TicksPerSecond = 60
+
+// World dimensions in the same units the client renders in.
+const (
+    WorldWidth  = 1280.0
+    WorldHeight = 720.0
+)
+
+// Bird and pipe geometry, mirroring the sprite sizes and the trimmed
+// bounding boxes used by the client's collision check.
+const (
+    BirdX          = 250.0
+    BirdStartY     = 250.0
+    BirdHeight     = 125.0
+    BirdHitbox     = 95.0
+    PipeWidth      = 185.0
+    PipeHitbox     = 175.0
+    TopPipeOverlap = 20.0
+)
+
+// Physics holds the tunable movement values for a simulation.
+type Physics struct {
+    Gravity      float64 // added to the bird's velocity every tick
+    JumpStrength float64 // velocity applied on a flap
+    PipeSpeed    float64 // distance pipes move left every tick
+    SpeedRamp    float64 // added to the pipe speed every tick
+}
+
+// DefaultPhysics returns the values the game has always used.
+func DefaultPhysics() Physics {
+    return Physics{
+        Gravity:      0.5,
+        JumpStrength: -10,
+        PipeSpeed:    2,
+    }
+}
+
+// Scroll returns how far the course has moved left after the given number of ticks.
+func (p Physics) Scroll(tick int64) float64 {
+    t := float64(tick)
+    return p.PipeSpeed*t + p.SpeedRamp*t*(t-1)/2
+}
@@ -0,0 +1,20 @@
+package simulation
+
+// Random is a mulberry32 generator. It is small enough to port to the
+// frontend byte for byte, so a course can be rebuilt from its seed anywhere.
+type Random struct {
+    state uint32
+}
+
+func NewRandom(seed uint32) *Random {
+    return &Random{state: seed}
+}
+
+// Float64 returns the next value in [0, 1).
+func (r *Random) Float64() float64 {
+    r.state += 0x6D2B79F5
+    t := r.state
+    t = (t ^ t>>15) * (t | 1)
+    t = (t + (t^t>>7)*(t|61)) ^ t
+    return float64(t^t>>14) / 4294967296
+}
@@ -0,0 +1,150 @@
+package simulation
+
+// EventType identifies something that happened to a bird during a step.
+type EventType string
+
+const (
+    EventScore EventType = "score"
+    EventDeath EventType = "dead"
+)
+
+// Event is produced by the simulation whenever a bird scores or dies.
+type Event struct {
+    Type   EventType
+    UserID string
+    Tick   int64
+}
+
+// Bird is the simulated state of a single player.
+type Bird struct {
+    Y        float64
+    Velocity float64
+    Alive    bool
+    Score    int
+    nextPipe int
+}
+
+// World simulates every bird in a game on a shared course.
+type World struct {
+    Physics Physics
+    Course  *Course
+    Birds   map[string]*Bird
+    Tick    int64
+}
+
+func NewWorld(physics Physics, course *Course) *World {
+    return &World{
+        Physics: physics,
+        Course:  course,
+        Birds:   make(map[string]*Bird),
+    }
+}
+
+// AddBird places a new live bird for userID at the starting position.
+func (w *World) AddBird(userID string) *Bird {
+    bird := &Bird{Y: BirdStartY, Alive: true}
+    w.Birds[userID] = bird
+    return bird
+}
+
+// Flap applies a jump to the user's bird. It reports whether the bird was alive.
+func (w *World) Flap(userID string) bool {
+    bird, exists := w.Birds[userID]
+    if !exists || !bird.Alive {
+        return false
+    }
+    bird.Velocity = w.Physics.JumpStrength
+    return true
+}
+
+// Kill removes a bird from play without a collision, e.g. when its player leaves.
+func (w *World) Kill(userID string) bool {
+    bird, exists := w.Birds[userID]
+    if !exists || !bird.Alive {
+        return false
+    }
+    bird.Alive = false
+    return true
+}
+
+// AdvanceTo steps the world until it reaches tick and returns everything that happened.
+func (w *World) AdvanceTo(tick int64) []Event {
+    var events []Event
+    for w.Tick < tick {
+        events = append(events, w.Step()...)
+    }
+    return events
+}
+
+// Step advances the world by a single tick.
+func (w *World) Step() []Event {
+    w.Tick++
+
+    var events []Event
+    for userID, bird := range w.Birds {
+        if !bird.Alive {
+            continue
+        }
+
+        bird.Y += bird.Velocity
+        bird.Velocity += w.Physics.Gravity
+
+        if bird.Y < 0 {
+            bird.Y = 0
+            bird.Velocity = 0
+        }
+
+        // Count every pipe the bird has fully cleared
+        for w.pipeX(bird.nextPipe)+PipeWidth < BirdX {
+            bird.nextPipe++
+            bird.Score++
+            events = append(events, Event{Type: EventScore, UserID: userID, Tick: w.Tick})
+        }
+
+        if bird.Y+BirdHeight > WorldHeight || w.collides(bird) {
+            bird.Alive = false
+            events = append(events, Event{Type: EventDeath, UserID: userID, Tick: w.Tick})
+        }
+    }
+    return events
+}
+
+// AllDead reports whether no bird is still flying.
+func (w *World) AllDead() bool {
+    for _, bird := range w.Birds {
+        if bird.Alive {
+            return false
+        }
+    }
+    return true
+}
+
+// NextPipe returns the next pipe the user's bird has to clear and its current
+// horizontal position.
+func (w *World) NextPipe(userID string) (Pipe, float64, bool) {
+    bird, exists := w.Birds[userID]
+    if !exists {
+        return Pipe{}, 0, false
+    }
+    return w.Course.Pipe(bird.nextPipe), w.pipeX(bird.nextPipe), true
+}
+
+// pipeX returns the current horizontal position of the i-th pipe.
+func (w *World) pipeX(i int) float64 {
+    return w.Course.Pipe(i).X - w.Physics.Scroll(w.Tick)
+}
+
+// collides checks the bird against the next pipe it has to clear. Pipes are
+// spaced wider than the bird, so no other pipe can overlap it.
+func (w *World) collides(bird *Bird) bool {
+    pipe := w.Course.Pipe(bird.nextPipe)
+    x := w.pipeX(bird.nextPipe)
+
+    if BirdX >= x+PipeHitbox || BirdX+BirdHitbox <= x {
+        return false
+    }
+
+    hitsTop := bird.Y < pipe.GapTop-TopPipeOverlap
+    hitsBottom := bird.Y+BirdHitbox > pipe.GapTop+pipe.GapSize
+    return hitsTop || hitsBottom
+}
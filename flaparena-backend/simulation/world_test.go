@@ -0,0 +1,122 @@
+package simulation
+
+import (
+    "reflect"
+    "testing"
+)
+
+// fixedCourse returns a course whose every gap starts at gapTop.
+func fixedCourse(gapTop float64) *Course {
+    return NewCourse(CourseParams{Spacing: 450, GapSize: 250, MinGapTop: gapTop, MaxGapTop: gapTop})
+}
+
+// hovering is physics without gravity, so birds keep their height.
+var hovering = Physics{JumpStrength: -10, PipeSpeed: 2}
+
+func TestBirdHitsFloor(t *testing.T) {
+    world := NewWorld(DefaultPhysics(), fixedCourse(50))
+    world.AddBird("a")
+
+    // The bird falls 0.25*n*(n-1) in n ticks and reaches the floor at 595
+    events := world.AdvanceTo(100)
+    want := []Event{{Type: EventDeath, UserID: "a", Tick: 38}}
+    if !reflect.DeepEqual(events, want) {
+        t.Fatalf("events %+v, want %+v", events, want)
+    }
+    if !world.AllDead() {
+        t.Fatal("bird is still alive after hitting the floor")
+    }
+}
+
+func TestBirdStopsAtCeiling(t *testing.T) {
+    world := NewWorld(DefaultPhysics(), fixedCourse(50))
+    bird := world.AddBird("a")
+    bird.Y = 5
+
+    world.Flap("a")
+    if events := world.Step(); len(events) != 0 {
+        t.Fatalf("events %+v at the ceiling", events)
+    }
+    if bird.Y != 0 || bird.Velocity != 0 || !bird.Alive {
+        t.Fatalf("bird at y %v with velocity %v, alive %v", bird.Y, bird.Velocity, bird.Alive)
+    }
+}
+
+func TestBirdHitsPipe(t *testing.T) {
+    tests := []struct {
+        name   string
+        gapTop float64
+    }{
+        // The bird flies at 250 with a hitbox 95 high
+        {"top pipe", 450},
+        {"bottom pipe", 50},
+    }
+
+    for _, test := range tests {
+        t.Run(test.name, func(t *testing.T) {
+            world := NewWorld(hovering, fixedCourse(test.gapTop))
+            world.AddBird("a")
+
+            // The first pipe reaches the bird once 1280 - 2t < 250 + 95
+            events := world.AdvanceTo(1000)
+            want := []Event{{Type: EventDeath, UserID: "a", Tick: 468}}
+            if !reflect.DeepEqual(events, want) {
+                t.Fatalf("events %+v, want %+v", events, want)
+            }
+        })
+    }
+}
+
+func TestBirdScoresThroughGaps(t *testing.T) {
+    world := NewWorld(hovering, fixedCourse(200))
+    bird := world.AddBird("a")
+
+    // A pipe is cleared once its right edge is behind the bird: x + 185 < 250
+    events := world.AdvanceTo(900)
+    want := []Event{
+        {Type: EventScore, UserID: "a", Tick: 608},
+        {Type: EventScore, UserID: "a", Tick: 833},
+    }
+    if !reflect.DeepEqual(events, want) {
+        t.Fatalf("events %+v, want %+v", events, want)
+    }
+    if !bird.Alive || bird.Score != 2 {
+        t.Fatalf("bird alive %v with score %d", bird.Alive, bird.Score)
+    }
+    if pipe, x, _ := world.NextPipe("a"); pipe != world.Course.Pipe(2) || x != 2180-1800 {
+        t.Fatalf("next pipe %+v at %v", pipe, x)
+    }
+}
+
+func TestDeadBirdsStayDown(t *testing.T) {
+    world := NewWorld(DefaultPhysics(), fixedCourse(50))
+    bird := world.AddBird("a")
+
+    if !world.Kill("a") || world.Kill("a") {
+        t.Fatal("bird could not be killed exactly once")
+    }
+    if world.Flap("a") {
+        t.Fatal("dead bird flapped")
+    }
+    if events := world.AdvanceTo(100); len(events) != 0 || bird.Y != BirdStartY {
+        t.Fatalf("dead bird moved to %v with events %+v", bird.Y, events)
+    }
+}
+
+func TestScroll(t *testing.T) {
+    physics := Physics{PipeSpeed: 2, SpeedRamp: 0.01}
+    tests := []struct {
+        tick int64
+        want float64
+    }{
+        {0, 0},
+        {1, 2},
+        {10, 20.45},
+    }
+
+    for _, test := range tests {
+        if got := physics.Scroll(test.tick); got != test.want {
+            t.Fatalf("scroll after %d ticks: got %v, want %v", test.tick, got, test.want)
+        }
+    }
+}
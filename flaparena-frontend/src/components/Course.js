@@ -0,0 +1,68 @@
+// Ports of the server's simulation.Random and simulation.Course. Both sides
+// have to produce the exact same pipes from a course's params, so keep them
+// in step with flaparena-backend/simulation.
+
+// World dimensions the server simulates in.
+export const WORLD_WIDTH = 1280;
+export const WORLD_HEIGHT = 720;
+
+// The pipe speed every room starts at, in world units per tick.
+export const PIPE_SPEED = 2;
+
+// Random is a mulberry32 generator, matching simulation.Random value for value.
+export class Random {
+  constructor(seed) {
+    this.state = seed >>> 0;
+  }
+
+  // next returns the next value in [0, 1).
+  next() {
+    this.state = (this.state + 0x6D2B79F5) >>> 0;
+    let t = this.state;
+    t = Math.imul(t ^ (t >>> 15), t | 1);
+    t = (t + Math.imul(t ^ (t >>> 7), t | 61)) ^ t;
+    return ((t ^ (t >>> 14)) >>> 0) / 4294967296;
+  }
+}
+
+// defaultCourseParams mirrors simulation.DefaultCourseParams, for a game
+// opened without a course from the server.
+export function defaultCourseParams(seed) {
+  return {
+    seed,
+    spacing: 450,
+    gapSize: 250,
+    minGapTop: 50,
+    maxGapTop: WORLD_HEIGHT - 250,
+  };
+}
+
+// Course lazily generates the pipes of a course. Pipe i always consumes the
+// i-th value of the seeded generator, so the layout only depends on the params.
+export class Course {
+  constructor(params) {
+    this.params = params;
+    this.random = new Random(params.seed);
+    this.pipes = [];
+  }
+
+  // pipe returns the i-th pipe, with its horizontal position at tick 0.
+  pipe(i) {
+    while (this.pipes.length <= i) {
+      const n = this.pipes.length;
+      const gapRange = this.params.maxGapTop - this.params.minGapTop;
+      this.pipes.push({
+        x: WORLD_WIDTH + n * this.params.spacing,
+        gapTop: this.params.minGapTop + this.random.next() * gapRange,
+        gapSize: this.params.gapSize,
+      });
+    }
+    return this.pipes[i];
+  }
+}
+
+// scroll returns how far the course has moved left after the given number
+// of ticks, like simulation.Physics.Scroll. speedRamp is per tick.
+export function scroll(tick, speedRamp) {
+  return PIPE_SPEED * tick + speedRamp * tick * (tick - 1) / 2;
+}
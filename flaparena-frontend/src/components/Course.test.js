@@ -0,0 +1,39 @@
+import { Random, Course, defaultCourseParams, scroll } from './Course';
+
+// The same golden values as flaparena-backend/simulation/course_test.go, so
+// both sides build the exact same pipes.
+
+test('Random matches the server sequence', () => {
+  const sequences = {
+    123456789: [0.2577907438389957, 0.9707721115555614, 0.7853280142880976, 0.20616457983851433, 0.30307188746519387],
+    0: [0.26642920868471265, 0.0003297457005828619, 0.2232720274478197, 0.1462021479383111, 0.46732782293111086],
+    4294967295: [0.8964226141106337, 0.189478256739676, 0.7156526781618595, 0.9440599093213677, 0.8452364315744489],
+  };
+
+  for (const [seed, values] of Object.entries(sequences)) {
+    const random = new Random(Number(seed));
+    expect(values.map(() => random.next())).toEqual(values);
+  }
+});
+
+test('Course matches the server pipes', () => {
+  const want = {
+    0: { x: 1280, gapTop: 158.2721124123782, gapSize: 250 },
+    1: { x: 1730, gapTop: 457.7242868533358, gapSize: 250 },
+    2: { x: 2180, gapTop: 379.837766001001, gapSize: 250 },
+    3: { x: 2630, gapTop: 136.58912353217602, gapSize: 250 },
+    10: { x: 5780, gapTop: 203.67838464211673, gapSize: 250 },
+  };
+
+  // Asking for a later pipe first must not change the earlier ones
+  const course = new Course(defaultCourseParams(123456789));
+  for (const i of [10, 0, 3, 1, 2]) {
+    expect(course.pipe(i)).toEqual(want[i]);
+  }
+});
+
+test('scroll matches the server', () => {
+  expect(scroll(0, 0.01)).toBe(0);
+  expect(scroll(1, 0.01)).toBe(2);
+  expect(scroll(10, 0.01)).toBe(20.45);
+});
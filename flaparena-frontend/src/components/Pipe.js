@@ -21,10 +21,6 @@ class PipePair {
     const bottomPipeY = this.gapTop + this.gapSize;
     ctx.drawImage(this.pipeImage, this.x, bottomPipeY, pipeWidth, canvasHeight - bottomPipeY);
   }
-
-  update() {
-    this.x -= 2; // Move pipes to the left
-  }
 }
 
 export default PipePair;
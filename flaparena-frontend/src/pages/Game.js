@@ -1,18 +1,24 @@
 import React, { useRef, useEffect } from 'react';
+import { useLocation } from 'react-router-dom';
 import backgroundImageSrc from '../assets/images/background.png';
 import Bird from '../components/Bird';
 import PipePair from '../components/Pipe';
 import pipeImageSrc from '../assets/images/pipe.png';
+import { Course, defaultCourseParams, scroll, WORLD_WIDTH, WORLD_HEIGHT } from '../components/Course';
 
 const Game = () => {
     const canvasRef = useRef(null);
     const birdPositionRef = useRef({ x: 250, y: 250 });
     const birdVelocityRef = useRef(0);
-    const pipesRef = useRef([]); // useRef for pipes to persist without causing re-renders
-    const gravity = 0.5;
-    const jumpStrength = -10;
-    const gapBetweenPipes = 450;
-    const gapBetweenTopBottomPipe = 250;
+
+    // The lobby hands over the gameStart message, so every player flies the
+    // course the server generated from its seed
+    const { state: gameStart } = useLocation();
+    const rules = gameStart?.rules;
+    const courseRef = useRef(new Course(gameStart?.course ?? defaultCourseParams(Math.floor(Math.random() * 4294967296))));
+    const gravity = rules?.gravity ?? 0.5;
+    const jumpStrength = rules?.jumpStrength ?? -10;
+    const speedRamp = (rules?.speedRamp ?? 0) / 60; // per tick, like the server
 
     useEffect(() => {
         // Load pipe images inside useEffect to ensure they're loaded after component mounts
@@ -32,20 +38,32 @@ const Game = () => {
         Promise.all(imageLoadPromises).then(() => {
             const canvas = canvasRef.current;
             const ctx = canvas.getContext('2d');
-            canvas.width = window.innerWidth;
-            canvas.height = window.innerHeight;
+            // Draw in the server's world units and let CSS scale the canvas
+            canvas.width = WORLD_WIDTH;
+            canvas.height = WORLD_HEIGHT;
+
+            const course = courseRef.current;
+            let tick = 0;
+            let firstPipe = 0;
 
             const draw = () => {
             ctx.clearRect(0, 0, canvas.width, canvas.height);
             ctx.drawImage(background, 0, 0, canvas.width, canvas.height);
 
-            pipesRef.current.forEach(pipePair => {
+            // Pipes sit wherever the course has scrolled them by this tick
+            const offset = scroll(tick, speedRamp);
+            while (course.pipe(firstPipe).x - offset + pipeImage.width < 0) {
+                firstPipe++;
+            }
+            for (let i = firstPipe; course.pipe(i).x - offset < canvas.width; i++) {
+                const pipe = course.pipe(i);
+                const pipePair = new PipePair(pipe.x - offset, pipe.gapTop, pipe.gapSize, pipeImage);
                 pipePair.draw(ctx);
-                pipePair.update();
                 if (checkCollision(birdPositionRef.current, Bird.image, pipePair, pipeImage)) {
                     // wsRef.current.send(JSON.stringify({ action: "dead", timestamp: Date.now() }));
                 }
-            });
+            }
+            tick++;
 
             // Bird logic
             const currentPosition = birdPositionRef.current;
@@ -63,14 +81,6 @@ const Game = () => {
                 currentPosition.y = 0;
                 birdVelocityRef.current = 0;
             }
-
-            // Remove pipes that have gone off screen and add new pipes
-            pipesRef.current = pipesRef.current.filter(pipe => pipe.x + pipeImage.width > 0);
-            if (pipesRef.current.length === 0 || pipesRef.current[pipesRef.current.length - 1].x < canvas.width - gapBetweenPipes) {
-                // Add a new pipe at the right edge of the canvas
-                const gapTop = Math.random() * (canvas.height - 300) + 50; // Randomize the gap position
-                pipesRef.current.push(new PipePair(canvas.width, gapTop, gapBetweenTopBottomPipe, pipeImage));
-            }
             };
 
             const gameLoop = setInterval(draw, 1000 / 60);
@@ -92,7 +102,7 @@ const Game = () => {
                 window.removeEventListener('keydown', handleKeyDown);
             };
         });
-    }, []);
+    }, [gravity, jumpStrength, speedRamp]);
 
     return <canvas ref={canvasRef} style={{ width: '100%', height: '100vh', display: 'block' }} />;
 };
@@ -121,7 +131,7 @@ function checkCollision(birdPosition, birdImage, pipePair, pipeImage) {
         x: pipePair.x,
         y: pipePair.gapTop + pipePair.gapSize,
         width: pipeImage.width - 10,
-        height: window.innerHeight - (pipePair.gapTop + pipePair.gapSize) + 15
+        height: WORLD_HEIGHT - (pipePair.gapTop + pipePair.gapSize) + 15
     };
     
     // Check for overlaps between the bird's bounding box and the pipe bounding boxes
@@ -1,4 +1,5 @@
 import React, { useState, useEffect, useRef } from 'react';
+import { useNavigate } from 'react-router-dom';
 import backgroundImageSrc from '../assets/images/background.png';
 
 const BASEURL = "localhost:8000";
@@ -7,6 +8,7 @@ const Lobby = () => {
     const [users, setUsers] = useState([]);
     const [isLoggedIn, setIsLoggedIn] = useState(false);
     const wsRef = useRef(null);
+    const navigate = useNavigate();
 
     const handleLoginSubmit = async (event) => {
         event.preventDefault();
@@ -106,6 +108,10 @@ const Lobby = () => {
                         ready: user.ready
                     })));
                     break;
+                case 'gameStart':
+                    // The game page builds its pipes from the course in here
+                    navigate('/game', { state: message.data });
+                    break;
                 default:
                     console.log("Received message: ", message);
             }